@@ -0,0 +1,165 @@
+package sqlbuilder
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+)
+
+// arrayThreshold is a minimal number of IN list values
+// to be bound as a single array with PostgreSQL dialect.
+var arrayThreshold int32
+
+/*
+SetArrayThreshold makes statements built with PostgreSQL dialect bind
+lists of at least n values as a single array argument.
+A list passed to a IN (?) predicate is then rendered as = ANY($1)
+and a NOT IN (?) one as <> ALL($1).
+It helps to stay below the PostgreSQL limit of 65535 parameters per statement.
+Zero, which is the default, disables the conversion.
+Use Any to bind a particular list as an array.
+*/
+func SetArrayThreshold(n int) {
+	atomic.StoreInt32(&arrayThreshold, int32(n))
+}
+
+// arrayList wraps a list to be bound as a single array argument.
+type arrayList struct {
+	values interface{}
+}
+
+/*
+Any marks a list to be bound as a single array argument when used
+with PostgreSQL dialect regardless of SetArrayThreshold:
+	stmt := sqlbuilder.UsingPostgresql().
+		From("events").
+		Select("id").
+		Where("user_id IN (?)", sqlbuilder.Any(userIds))
+produces
+	SELECT id FROM events WHERE user_id = ANY($1)
+Outside of IN (?) the list is bound as a single array argument too:
+	stmt.Where("user_id = ANY(?)", sqlbuilder.Any(userIds))
+With dialects not supporting arrays the list of IN (?) is expanded
+into a list of placeholders, and any other use is reported by Err.
+*/
+func Any(values interface{}) interface{} {
+	return arrayList{values: values}
+}
+
+/*
+Array returns a driver.Valuer that encodes a slice as a PostgreSQL array literal.
+Slices of integers, floats, booleans, strings, UUIDs ([16]byte based types)
+and driver.Valuer values are supported:
+	stmt.Where("id = ANY(?)", sqlbuilder.Array([]int64{1, 2, 3}))
+The literal is passed to a driver as a string, so no driver specific array type is needed.
+A nil slice is encoded as an empty array, not as NULL.
+*/
+func Array(values interface{}) driver.Valuer {
+	return pgArray{values: values}
+}
+
+// pgArray encodes a slice as a PostgreSQL array literal.
+type pgArray struct {
+	values interface{}
+}
+
+// Value implements driver.Valuer interface.
+func (a pgArray) Value() (driver.Value, error) {
+	v := reflect.ValueOf(a.values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("sqlbuilder: can not encode %T as an array", a.values)
+	}
+	b := make([]byte, 0, 2+v.Len()*8)
+	b = append(b, '{')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		var err error
+		b, err = appendArrayItem(b, v.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+	}
+	b = append(b, '}')
+	return string(b), nil
+}
+
+// appendArrayItem appends an array literal item to b.
+func appendArrayItem(b []byte, item interface{}) ([]byte, error) {
+	if valuer, ok := item.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return b, err
+		}
+		item = value
+	}
+	switch v := item.(type) {
+	case nil:
+		return append(b, "NULL"...), nil
+	case string:
+		return appendArrayString(b, v), nil
+	case bool:
+		if v {
+			return append(b, 't'), nil
+		}
+		return append(b, 'f'), nil
+	case [16]byte:
+		return appendUUID(b, v), nil
+	}
+	v := reflect.ValueOf(item)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(b, v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.AppendUint(b, v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.AppendFloat(b, v.Float(), 'g', -1, 64), nil
+	case reflect.String:
+		return appendArrayString(b, v.String()), nil
+	case reflect.Bool:
+		return appendArrayItem(b, v.Bool())
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Len() == 16 {
+			var uuid [16]byte
+			reflect.Copy(reflect.ValueOf(&uuid).Elem(), v)
+			return appendUUID(b, uuid), nil
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			return append(b, "NULL"...), nil
+		}
+		return appendArrayItem(b, v.Elem().Interface())
+	}
+	return b, fmt.Errorf("sqlbuilder: can not encode %T as an array item", item)
+}
+
+// appendArrayString appends a double quoted array item to b.
+func appendArrayString(b []byte, s string) []byte {
+	b = append(b, '"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b = append(b, '\\')
+		}
+		b = append(b, s[i])
+	}
+	return append(b, '"')
+}
+
+// appendUUID appends a UUID in its canonical form to b.
+func appendUUID(b []byte, uuid [16]byte) []byte {
+	var buf [36]byte
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,123 @@
+package sqlbuilder_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sqlbuilder"
+)
+
+func TestArray(t *testing.T) {
+	value, err := sqlbuilder.Array([]int64{1, 2, 3}).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, driver.Value("{1,2,3}"), value)
+
+	value, err = sqlbuilder.Array([]string{"a", `b"c`, `d\e`}).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, driver.Value(`{"a","b\"c","d\\e"}`), value)
+
+	uuid := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	value, err = sqlbuilder.Array([][16]byte{uuid}).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, driver.Value("{123e4567-e89b-12d3-a456-426614174000}"), value)
+
+	_, err = sqlbuilder.Array([]struct{}{{}}).Value()
+	assert.Error(t, err)
+}
+
+func TestArrayThreshold(t *testing.T) {
+	sqlbuilder.SetArrayThreshold(3)
+	defer sqlbuilder.SetArrayThreshold(0)
+
+	q := sqlbuilder.UsingPostgresql().
+		From("events").
+		Select("id").
+		Where("user_id IN (?)", []int{1, 2, 3}).
+		Where("kind NOT IN (?)", []string{"a", "b"}).
+		Where("id").In(4, 5, 6)
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM events WHERE user_id = ANY($1) AND kind NOT IN ($2, $3) AND id = ANY($4)", q.String())
+	assert.Equal(t, []interface{}{sqlbuilder.Array([]int{1, 2, 3}), "a", "b", sqlbuilder.Array([]interface{}{4, 5, 6})}, q.Args())
+
+	q2 := sqlbuilder.From("events").
+		Select("id").
+		Where("user_id IN (?)", []int{1, 2, 3})
+	defer q2.Close()
+	assert.Equal(t, "SELECT id FROM events WHERE user_id IN (?, ?, ?)", q2.String())
+}
+
+func TestAny(t *testing.T) {
+	q := sqlbuilder.UsingPostgresql().
+		From("events").
+		Select("id").
+		Where("user_id IN (?) AND kind NOT IN ( ? )", sqlbuilder.Any([]int{}), sqlbuilder.Any([]string{"x"}))
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM events WHERE user_id = ANY($1) AND kind <> ALL($2)", q.String())
+	assert.Equal(t, []interface{}{sqlbuilder.Array([]int{}), sqlbuilder.Array([]string{"x"})}, q.Args())
+
+	q2 := sqlbuilder.WithDialect(sqlbuilder.MySQL).
+		From("events").
+		Select("id").
+		Where("user_id IN (?)", sqlbuilder.Any([]int{1, 2}))
+	defer q2.Close()
+	assert.Equal(t, "SELECT id FROM events WHERE user_id IN (?, ?)", q2.String())
+	assert.Equal(t, []interface{}{1, 2}, q2.Args())
+}
+
+type arrayDialect struct {
+	sqlbuilder.BaseDialect
+}
+
+func (arrayDialect) Placeholder(b []byte, argNo int) []byte {
+	return sqlbuilder.PostgreSQL.Placeholder(b, argNo)
+}
+
+func (d arrayDialect) Features() sqlbuilder.Feature {
+	return d.BaseDialect.Features() | sqlbuilder.FeatureArrays
+}
+
+func TestAnyCustomDialect(t *testing.T) {
+	q := sqlbuilder.WithDialect(arrayDialect{}).
+		From("events").
+		Select("id").
+		Where("user_id IN (?)", sqlbuilder.Any([]int{1, 2}))
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM events WHERE user_id = ANY($1)", q.String())
+	assert.Equal(t, []interface{}{sqlbuilder.Array([]int{1, 2})}, q.Args())
+}
+
+func TestAnyNil(t *testing.T) {
+	value, err := sqlbuilder.Array([]int(nil)).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, driver.Value("{}"), value)
+
+	q := sqlbuilder.UsingPostgresql().
+		From("events").
+		Select("id").
+		Where("id NOT IN (?)", sqlbuilder.Any([]int(nil)))
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM events WHERE id <> ALL($1)", q.String())
+	value, err = q.Args()[0].(driver.Valuer).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, driver.Value("{}"), value)
+}
+
+func TestAnyOutsideIn(t *testing.T) {
+	q := sqlbuilder.UsingPostgresql().
+		From("events").
+		Select("id").
+		Where("user_id = ANY(?) AND kind = ?", sqlbuilder.Any([]int{1, 2}), "click")
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM events WHERE user_id = ANY($1) AND kind = $2", q.String())
+	assert.Equal(t, []interface{}{sqlbuilder.Array([]int{1, 2}), "click"}, q.Args())
+	assert.NoError(t, q.Err())
+
+	q2 := sqlbuilder.WithDialect(sqlbuilder.MySQL).
+		From("events").
+		Select("id").
+		Where("user_id = ANY(?)", sqlbuilder.Any([]int{1, 2}))
+	defer q2.Close()
+	assert.EqualError(t, q2.Err(), `sqlbuilder: arrays bound by Any in "WHERE user_id = ANY(?)" are not supported by the dialect`)
+}
@@ -0,0 +1,202 @@
+package sqlbuilder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RowSource provides rows to be inserted by a BulkInserter.
+// Next returns io.EOF once there are no rows left.
+// A returned row may be reused by the following Next call.
+type RowSource interface {
+	Next() (row []interface{}, err error)
+}
+
+// RowsFunc is an adapter to use a function as a RowSource.
+type RowsFunc func() ([]interface{}, error)
+
+// Next calls f.
+func (f RowsFunc) Next() ([]interface{}, error) {
+	return f()
+}
+
+// RowSlice returns a RowSource that iterates over a slice of rows.
+func RowSlice(rows [][]interface{}) RowSource {
+	n := 0
+	return RowsFunc(func() ([]interface{}, error) {
+		if n == len(rows) {
+			return nil, io.EOF
+		}
+		n++
+		return rows[n-1], nil
+	})
+}
+
+// BulkError is returned by BulkInserter if a chunk of rows fails to be inserted.
+type BulkError struct {
+	// Chunk is a zero based number of a failed chunk
+	Chunk int
+	// FirstRow is a zero based index of the first row of the chunk
+	FirstRow int
+	// Rows is the number of rows in the chunk
+	Rows int
+	Err  error
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("sqlbuilder: bulk insert of chunk %d (rows %d-%d) failed: %v",
+		e.Chunk, e.FirstRow, e.FirstRow+e.Rows-1, e.Err)
+}
+
+// Unwrap returns the error the chunk has failed with.
+func (e *BulkError) Unwrap() error {
+	return e.Err
+}
+
+// txBeginner is implemented by sql.DB and sql.Conn.
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+/*
+BulkInserter inserts an arbitrary number of rows with multi-row INSERT statements.
+Rows are split into chunks to keep the number of arguments of every statement
+below the limit of the statement Dialect:
+	n, err := sqlbuilder.BulkInsert("points", "x", "y").
+		InTx().
+		Exec(ctx, db, sqlbuilder.RowSlice(rows))
+*/
+type BulkInserter struct {
+	dialect Dialect
+	table   string
+	columns []string
+	maxArgs int
+	inTx    bool
+}
+
+// BulkInsert creates a BulkInserter for a table and a list of columns.
+// It uses the Dialect selected by SetDialect.
+func BulkInsert(tableName string, columns ...string) *BulkInserter {
+	return &BulkInserter{
+		dialect: defaultDialect(),
+		table:   tableName,
+		columns: columns,
+	}
+}
+
+// WithDialect sets a Dialect to build statements with.
+func (b *BulkInserter) WithDialect(d Dialect) *BulkInserter {
+	b.dialect = d
+	return b
+}
+
+// MaxArgs overrides the maximum number of arguments per statement
+// reported by the Dialect.
+func (b *BulkInserter) MaxArgs(n int) *BulkInserter {
+	b.maxArgs = n
+	return b
+}
+
+// InTx makes Exec insert all the chunks inside a single transaction.
+// The executor passed to Exec must be able to begin one, like sql.DB or sql.Conn do.
+func (b *BulkInserter) InTx() *BulkInserter {
+	b.inTx = true
+	return b
+}
+
+// ChunkSize returns the maximum number of rows inserted by a single statement.
+func (b *BulkInserter) ChunkSize() int {
+	maxArgs := b.maxArgs
+	if maxArgs <= 0 {
+		maxArgs = b.dialect.MaxArgs()
+	}
+	n := 1
+	if len(b.columns) > 0 {
+		n = maxArgs / len(b.columns)
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// Exec inserts rows provided by a RowSource and returns
+// the total number of rows affected.
+// If a chunk fails, a *BulkError is returned.
+// Inside a transaction nothing is inserted on failure.
+func (b *BulkInserter) Exec(ctx context.Context, db Executor, rows RowSource) (total int64, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if b.inTx {
+		beginner, ok := db.(txBeginner)
+		if !ok {
+			return 0, errors.New("sqlbuilder: bulk insert executor can not begin a transaction")
+		}
+		tx, err := beginner.BeginTx(ctx, nil)
+		if err != nil {
+			return 0, err
+		}
+		total, err = b.exec(ctx, tx, rows)
+		if err != nil {
+			ignoreErr(tx.Rollback())
+			return 0, err
+		}
+		return total, tx.Commit()
+	}
+	return b.exec(ctx, db, rows)
+}
+
+// exec inserts rows chunk by chunk.
+func (b *BulkInserter) exec(ctx context.Context, db Executor, rows RowSource) (total int64, err error) {
+	chunkSize := b.ChunkSize()
+	chunk, firstRow, n := 0, 0, 0
+	var stmt Statement
+	flush := func() error {
+		if stmt == nil {
+			return nil
+		}
+		res, err := stmt.ExecAndClose(ctx, db)
+		stmt = nil
+		if err == nil {
+			var affected int64
+			affected, err = res.RowsAffected()
+			total += affected
+		}
+		if err != nil {
+			return &BulkError{Chunk: chunk, FirstRow: firstRow, Rows: n - firstRow, Err: err}
+		}
+		chunk++
+		firstRow = n
+		return nil
+	}
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err == nil && len(row) != len(b.columns) {
+			err = fmt.Errorf("sqlbuilder: bulk insert row %d has %d values, %d expected", n, len(row), len(b.columns))
+		}
+		if err != nil {
+			if stmt != nil {
+				stmt.Close()
+			}
+			return total, err
+		}
+		if stmt == nil {
+			stmt = WithDialect(b.dialect).InsertInto(b.table).Columns(b.columns...)
+		}
+		stmt.Values(row...)
+		n++
+		if n-firstRow == chunkSize {
+			if err := flush(); err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, flush()
+}
@@ -0,0 +1,113 @@
+package sqlbuilder_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sqlbuilder"
+)
+
+type execResult int64
+
+func (r execResult) LastInsertId() (int64, error) { return 0, nil }
+func (r execResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+// recorder is an Executor that records executed statements.
+type recorder struct {
+	queries []string
+	args    [][]interface{}
+	fail    int
+}
+
+func (r *recorder) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	r.queries = append(r.queries, query)
+	r.args = append(r.args, append([]interface{}(nil), args...))
+	if len(r.queries) == r.fail {
+		return nil, errors.New("failed")
+	}
+	return execResult(len(args) / 2), nil
+}
+
+func (r *recorder) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *recorder) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func TestBulkInsert(t *testing.T) {
+	rows := [][]interface{}{{1, "a"}, {2, "b"}, {3, "c"}, {4, "d"}, {5, "e"}}
+	assert.Equal(t, 1049, sqlbuilder.BulkInsert("t", "a", "b").WithDialect(sqlbuilder.SQLServer).ChunkSize())
+
+	db := &recorder{}
+	n, err := sqlbuilder.BulkInsert("t", "a", "b").
+		WithDialect(sqlbuilder.PostgreSQL).
+		MaxArgs(5).
+		Exec(context.Background(), db, sqlbuilder.RowSlice(rows))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+	assert.Equal(t, []string{
+		"INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4)",
+		"INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4)",
+		"INSERT INTO t (a, b) VALUES ($1, $2)",
+	}, db.queries)
+	assert.Equal(t, []interface{}{5, "e"}, db.args[2])
+
+	db = &recorder{fail: 2}
+	n, err = sqlbuilder.BulkInsert("t", "a", "b").
+		MaxArgs(4).
+		Exec(context.Background(), db, sqlbuilder.RowSlice(rows))
+	assert.Equal(t, int64(2), n)
+	var bulkErr *sqlbuilder.BulkError
+	if assert.True(t, errors.As(err, &bulkErr)) {
+		assert.Equal(t, 1, bulkErr.Chunk)
+		assert.Equal(t, 2, bulkErr.FirstRow)
+		assert.Equal(t, 2, bulkErr.Rows)
+	}
+
+	_, err = sqlbuilder.BulkInsert("t", "a", "b").
+		InTx().
+		Exec(context.Background(), &recorder{}, sqlbuilder.RowSlice(rows))
+	assert.Error(t, err)
+}
+
+func TestBulkInsertSQLServerChunks(t *testing.T) {
+	rows := make([][]interface{}, 1050)
+	for n := range rows {
+		rows[n] = []interface{}{n, "x"}
+	}
+	db := &recorder{}
+	n, err := sqlbuilder.BulkInsert("t", "a", "b").
+		WithDialect(sqlbuilder.SQLServer).
+		Exec(context.Background(), db, sqlbuilder.RowSlice(rows))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1050), n)
+	if assert.Len(t, db.args, 2) {
+		assert.Len(t, db.args[0], 2098)
+		assert.Len(t, db.args[1], 2)
+	}
+}
+
+func TestBulkInsertSQLExecutorTx(t *testing.T) {
+	fake := &fakeDB{}
+	db := sqlbuilder.CreateSQLExecutor("test", fake.open(), false)
+	defer db.Close()
+
+	rows := [][]interface{}{{1, "a"}, {2, "b"}, {3, "c"}}
+	_, err := sqlbuilder.BulkInsert("t", "a", "b").
+		MaxArgs(4).
+		InTx().
+		Exec(context.Background(), db, sqlbuilder.RowSlice(rows))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"INSERT INTO t (a, b) VALUES (?, ?), (?, ?)",
+		"INSERT INTO t (a, b) VALUES (?, ?)",
+		"COMMIT",
+	}, fake.queries)
+}
@@ -0,0 +1,257 @@
+package sqlbuilder
+
+import (
+	"github.com/valyala/bytebufferpool"
+)
+
+/*
+Cond is a filtering condition to be passed to WhereCond and HavingCond methods.
+Conditions can be combined with And, Or and Not:
+	stmt := sqlbuilder.From("users").
+		Select("id").
+		WhereCond(sqlbuilder.Or(
+			sqlbuilder.Eq("role", "admin"),
+			sqlbuilder.And(
+				sqlbuilder.Eq("role", "editor"),
+				sqlbuilder.In("team_id", 1, 2, 3),
+			),
+		))
+produces
+	SELECT id FROM users WHERE (role = ? OR (role = ? AND team_id IN (?, ?, ?)))
+Column names are SQL fragments and are not escaped.
+*/
+type Cond interface {
+	// AppendSQL appends an SQL expression with ? placeholders to b
+	// and its arguments to args.
+	AppendSQL(b []byte, args []interface{}) ([]byte, []interface{})
+}
+
+// comparison is a condition comparing a column with a value.
+type comparison struct {
+	column string
+	op     string
+	value  interface{}
+}
+
+func (c comparison) AppendSQL(b []byte, args []interface{}) ([]byte, []interface{}) {
+	b = append(b, c.column...)
+	b = append(b, ' ')
+	b = append(b, c.op...)
+	b = append(b, " ?"...)
+	return b, append(args, c.value)
+}
+
+// nullCheck is an IS NULL or IS NOT NULL condition.
+type nullCheck struct {
+	column string
+	not    bool
+}
+
+func (c nullCheck) AppendSQL(b []byte, args []interface{}) ([]byte, []interface{}) {
+	b = append(b, c.column...)
+	if c.not {
+		return append(b, " IS NOT NULL"...), args
+	}
+	return append(b, " IS NULL"...), args
+}
+
+// inList is an IN or NOT IN condition.
+type inList struct {
+	column string
+	not    bool
+	values []interface{}
+}
+
+func (c inList) AppendSQL(b []byte, args []interface{}) ([]byte, []interface{}) {
+	// An empty list matches nothing, so NOT IN matches everything
+	if len(c.values) == 0 {
+		if c.not {
+			return append(b, "1=1"...), args
+		}
+		return append(b, "1=0"...), args
+	}
+	b = append(b, c.column...)
+	if c.not {
+		b = append(b, " NOT"...)
+	}
+	b = append(b, " IN ("...)
+	for n := range c.values {
+		if n > 0 {
+			b = append(b, ", "...)
+		}
+		b = append(b, '?')
+	}
+	b = append(b, ')')
+	return b, append(args, c.values...)
+}
+
+// between is a BETWEEN condition.
+type between struct {
+	column   string
+	from, to interface{}
+}
+
+func (c between) AppendSQL(b []byte, args []interface{}) ([]byte, []interface{}) {
+	b = append(b, c.column...)
+	b = append(b, " BETWEEN ? AND ?"...)
+	return b, append(args, c.from, c.to)
+}
+
+// raw is an arbitrary SQL condition.
+type raw struct {
+	expr string
+	args []interface{}
+}
+
+func (c raw) AppendSQL(b []byte, args []interface{}) ([]byte, []interface{}) {
+	return append(b, c.expr...), append(args, c.args...)
+}
+
+// junction combines conditions with AND or OR.
+type junction struct {
+	op    string
+	conds []Cond
+}
+
+func (c junction) AppendSQL(b []byte, args []interface{}) ([]byte, []interface{}) {
+	if len(c.conds) == 0 {
+		// An empty AND is true and an empty OR is false
+		if c.op == " AND " {
+			return append(b, "1=1"...), args
+		}
+		return append(b, "1=0"...), args
+	}
+	if len(c.conds) == 1 {
+		return appendOperand(b, args, c.conds[0])
+	}
+	b = append(b, '(')
+	for n, cond := range c.conds {
+		if n > 0 {
+			b = append(b, c.op...)
+		}
+		b, args = appendOperand(b, args, cond)
+	}
+	return append(b, ')'), args
+}
+
+// negation is a NOT condition.
+type negation struct {
+	cond Cond
+}
+
+func (c negation) AppendSQL(b []byte, args []interface{}) ([]byte, []interface{}) {
+	b = append(b, "NOT "...)
+	return appendOperand(b, args, c.cond)
+}
+
+// appendOperand appends a condition to be combined with other ones.
+// Conditions of unknown precedence are put in parentheses.
+func appendOperand(b []byte, args []interface{}, cond Cond) ([]byte, []interface{}) {
+	switch cond.(type) {
+	case comparison, nullCheck, inList, between, junction, negation:
+		return cond.AppendSQL(b, args)
+	}
+	b = append(b, '(')
+	b, args = cond.AppendSQL(b, args)
+	return append(b, ')'), args
+}
+
+// Eq creates a column = value condition.
+// A nil value produces a column IS NULL condition.
+func Eq(column string, value interface{}) Cond {
+	if value == nil {
+		return nullCheck{column: column}
+	}
+	return comparison{column: column, op: "=", value: value}
+}
+
+// Ne creates a column <> value condition.
+// A nil value produces a column IS NOT NULL condition.
+func Ne(column string, value interface{}) Cond {
+	if value == nil {
+		return nullCheck{column: column, not: true}
+	}
+	return comparison{column: column, op: "<>", value: value}
+}
+
+// Gt creates a column > value condition.
+func Gt(column string, value interface{}) Cond {
+	return comparison{column: column, op: ">", value: value}
+}
+
+// Ge creates a column >= value condition.
+func Ge(column string, value interface{}) Cond {
+	return comparison{column: column, op: ">=", value: value}
+}
+
+// Lt creates a column < value condition.
+func Lt(column string, value interface{}) Cond {
+	return comparison{column: column, op: "<", value: value}
+}
+
+// Le creates a column <= value condition.
+func Le(column string, value interface{}) Cond {
+	return comparison{column: column, op: "<=", value: value}
+}
+
+// Like creates a column LIKE pattern condition.
+func Like(column string, pattern interface{}) Cond {
+	return comparison{column: column, op: "LIKE", value: pattern}
+}
+
+// In creates a column IN (values...) condition.
+// An empty list of values produces a condition that is always false.
+func In(column string, values ...interface{}) Cond {
+	return inList{column: column, values: values}
+}
+
+// NotIn creates a column NOT IN (values...) condition.
+// An empty list of values produces a condition that is always true.
+func NotIn(column string, values ...interface{}) Cond {
+	return inList{column: column, not: true, values: values}
+}
+
+// Between creates a column BETWEEN from AND to condition.
+func Between(column string, from, to interface{}) Cond {
+	return between{column: column, from: from, to: to}
+}
+
+// IsNull creates a column IS NULL condition.
+func IsNull(column string) Cond {
+	return nullCheck{column: column}
+}
+
+// IsNotNull creates a column IS NOT NULL condition.
+func IsNotNull(column string) Cond {
+	return nullCheck{column: column, not: true}
+}
+
+// Raw creates a condition from an SQL expression with ? placeholders.
+func Raw(expr string, args ...interface{}) Cond {
+	return raw{expr: expr, args: args}
+}
+
+// And combines conditions with AND. An empty list of conditions is always true.
+func And(conds ...Cond) Cond {
+	return junction{op: " AND ", conds: conds}
+}
+
+// Or combines conditions with OR. An empty list of conditions is always false.
+func Or(conds ...Cond) Cond {
+	return junction{op: " OR ", conds: conds}
+}
+
+// Not negates a condition.
+func Not(cond Cond) Cond {
+	return negation{cond: cond}
+}
+
+// condString renders a condition to an expression to be added
+// to WHERE or HAVING clause.
+func condString(cond Cond, args []interface{}) (string, []interface{}) {
+	buf := bytebufferpool.Get()
+	buf.B, args = appendOperand(buf.B, args, cond)
+	expr := buf.String()
+	bytebufferpool.Put(buf)
+	return expr, args
+}
@@ -0,0 +1,50 @@
+package sqlbuilder_test
+
+import (
+	"sqlbuilder"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhereCond(t *testing.T) {
+	q := sqlbuilder.UsingPostgresql().
+		From("users").
+		Select("id").
+		Where("is_active").
+		WhereCond(sqlbuilder.Or(
+			sqlbuilder.Eq("role", "admin"),
+			sqlbuilder.And(
+				sqlbuilder.Eq("role", "editor"),
+				sqlbuilder.In("team_id", 1, 2),
+				sqlbuilder.Not(sqlbuilder.Between("age", 10, 17)),
+			),
+			sqlbuilder.Raw("score > ? OR bonus > ?", 90, 5),
+		))
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM users WHERE is_active AND (role = $1 OR (role = $2 AND team_id IN ($3, $4) AND NOT age BETWEEN $5 AND $6) OR (score > $7 OR bonus > $8))", q.String())
+	assert.Equal(t, []interface{}{"admin", "editor", 1, 2, 10, 17, 90, 5}, q.Args())
+}
+
+func TestCondNulls(t *testing.T) {
+	q := sqlbuilder.From("users").
+		Select("id").
+		WhereCond(sqlbuilder.Eq("deleted_at", nil)).
+		WhereCond(sqlbuilder.Ne("email", nil)).
+		WhereCond(sqlbuilder.In("id")).
+		WhereCond(sqlbuilder.NotIn("id")).
+		WhereCond(sqlbuilder.Or())
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM users WHERE deleted_at IS NULL AND email IS NOT NULL AND 1=0 AND 1=1 AND 1=0", q.String())
+	assert.Empty(t, q.Args())
+}
+
+func TestHavingCond(t *testing.T) {
+	q := sqlbuilder.From("orders").
+		Select("user_id, SUM(amount)").
+		GroupBy("user_id").
+		HavingCond(sqlbuilder.And(sqlbuilder.Gt("SUM(amount)", 100), sqlbuilder.Le("COUNT(*)", 10)))
+	defer q.Close()
+	assert.Equal(t, "SELECT user_id, SUM(amount) FROM orders GROUP BY user_id HAVING (SUM(amount) > ? AND COUNT(*) <= ?)", q.String())
+	assert.Equal(t, []interface{}{100, 10}, q.Args())
+}
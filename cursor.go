@@ -0,0 +1,88 @@
+package sqlbuilder
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+/*
+Cursor iterates over rows returned by a statement.
+It's returned by Iter method:
+	cur, err := stmt.Iter(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer cur.Close()
+	for cur.Next() {
+		if err := cur.Scan(); err != nil {
+			return err
+		}
+		if done() {
+			break
+		}
+	}
+	return cur.Err()
+*/
+type Cursor struct {
+	rows *sql.Rows
+	dest []interface{}
+
+	columns    []string
+	structType reflect.Type
+	indexes    [][]int
+}
+
+// Next prepares the next row to be scanned.
+// It returns false when there are no rows left or an error occurs.
+func (cur *Cursor) Next() bool {
+	return cur.rows.Next()
+}
+
+// Scan scans the current row into variables bound via To method calls.
+func (cur *Cursor) Scan() error {
+	return cur.rows.Scan(cur.dest...)
+}
+
+// ScanStruct scans the current row into a struct dest points to.
+// Columns are mapped to struct fields via db tags regardless of their order.
+// A column not mapped to a field or a mapped field missing in the result set
+// is reported as an error.
+func (cur *Cursor) ScanStruct(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sqlbuilder: can not scan a row into %T, a pointer to a struct expected", dest)
+	}
+	v = v.Elem()
+	if cur.structType != v.Type() {
+		if cur.columns == nil {
+			columns, err := cur.rows.Columns()
+			if err != nil {
+				return err
+			}
+			cur.columns = columns
+		}
+		indexes, err := columnFields(cur.columns, v.Type())
+		if err != nil {
+			return err
+		}
+		cur.structType, cur.indexes = v.Type(), indexes
+	}
+	return scanStruct(cur.rows, v, cur.columns, cur.indexes)
+}
+
+// Rows returns the underlying sql.Rows.
+func (cur *Cursor) Rows() *sql.Rows {
+	return cur.rows
+}
+
+// Err returns an error occurred during iteration.
+func (cur *Cursor) Err() error {
+	return cur.rows.Err()
+}
+
+// Close closes the cursor. It's safe to call Close several times.
+// The statement the cursor has been created by is to be closed separately.
+func (cur *Cursor) Close() error {
+	return cur.rows.Close()
+}
@@ -0,0 +1,79 @@
+package sqlbuilder_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sqlbuilder"
+)
+
+func TestIter(t *testing.T) {
+	fake := &fakeDB{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{int64(1), "acme"}, {int64(2), "initech"}, {int64(3), "hooli"}},
+	}
+	db := fake.open()
+	defer db.Close()
+
+	var (
+		id   int64
+		name string
+		ids  []int64
+	)
+	q := sqlbuilder.From("orgs").Select("id").To(&id).Select("name").To(&name)
+	defer q.Close()
+	cur, err := q.Iter(context.Background(), db)
+	assert.NoError(t, err)
+	for cur.Next() {
+		assert.NoError(t, cur.Scan())
+		ids = append(ids, id)
+		if id == 2 {
+			break
+		}
+	}
+	assert.NoError(t, cur.Err())
+	assert.NoError(t, cur.Close())
+	assert.Equal(t, []int64{1, 2}, ids)
+
+	var orgs []org
+	cur, err = sqlbuilder.From("orgs").Select("id, name").Iter(context.Background(), db)
+	assert.NoError(t, err)
+	defer cur.Close()
+	for cur.Next() {
+		var o org
+		assert.NoError(t, cur.ScanStruct(&o))
+		orgs = append(orgs, o)
+	}
+	assert.Equal(t, []org{{1, "acme"}, {2, "initech"}, {3, "hooli"}}, orgs)
+}
+
+func TestQueryFunc(t *testing.T) {
+	fake := &fakeDB{
+		columns: []string{"id"},
+		rows:    [][]driver.Value{{int64(1)}, {int64(2)}, {int64(3)}},
+	}
+	db := fake.open()
+	defer db.Close()
+
+	errStop := errors.New("stop")
+	var (
+		id  int64
+		ids []int64
+	)
+	err := sqlbuilder.From("orgs").
+		Select("id").To(&id).
+		QueryFuncAndClose(context.Background(), db, func(rows *sql.Rows) error {
+			if id == 2 {
+				return errStop
+			}
+			ids = append(ids, id)
+			return nil
+		})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, []int64{1}, ids)
+}
@@ -0,0 +1,606 @@
+package sqlbuilder
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// TypeKind is a kind of a column type independent from a database.
+type TypeKind int
+
+const (
+	// TypeCustom is a type with a name given as is
+	TypeCustom TypeKind = iota
+	TypeSmallInt
+	TypeInteger
+	TypeBigInt
+	// TypeSerial is an auto incremented integer
+	TypeSerial
+	// TypeBigSerial is an auto incremented big integer
+	TypeBigSerial
+	TypeBoolean
+	TypeDouble
+	// TypeDecimal is a fixed point number of Size digits with Scale of them after the point
+	TypeDecimal
+	// TypeVarchar is a string of Size characters at most
+	TypeVarchar
+	TypeText
+	TypeBytes
+	TypeDate
+	TypeTimestamp
+	// TypeTimestampTZ is a timestamp with a time zone
+	TypeTimestampTZ
+	TypeUUID
+	TypeJSON
+)
+
+// ColumnType is a column type to be rendered by a Dialect.
+type ColumnType struct {
+	Kind  TypeKind
+	Size  int
+	Scale int
+	// Name is a type name for TypeCustom kind
+	Name string
+}
+
+// Column types to be passed to CreateTable and AlterTable builders.
+var (
+	SmallInt    = ColumnType{Kind: TypeSmallInt}
+	Integer     = ColumnType{Kind: TypeInteger}
+	BigInt      = ColumnType{Kind: TypeBigInt}
+	Serial      = ColumnType{Kind: TypeSerial}
+	BigSerial   = ColumnType{Kind: TypeBigSerial}
+	Boolean     = ColumnType{Kind: TypeBoolean}
+	Double      = ColumnType{Kind: TypeDouble}
+	Text        = ColumnType{Kind: TypeText}
+	Bytes       = ColumnType{Kind: TypeBytes}
+	Date        = ColumnType{Kind: TypeDate}
+	Timestamp   = ColumnType{Kind: TypeTimestamp}
+	TimestampTZ = ColumnType{Kind: TypeTimestampTZ}
+	UUID        = ColumnType{Kind: TypeUUID}
+	JSON        = ColumnType{Kind: TypeJSON}
+)
+
+// Varchar returns a type of strings of size characters at most.
+func Varchar(size int) ColumnType {
+	return ColumnType{Kind: TypeVarchar, Size: size}
+}
+
+// Decimal returns a type of fixed point numbers.
+func Decimal(precision, scale int) ColumnType {
+	return ColumnType{Kind: TypeDecimal, Size: precision, Scale: scale}
+}
+
+// Type returns a database specific type rendered as is.
+func Type(name string) ColumnType {
+	return ColumnType{Kind: TypeCustom, Name: name}
+}
+
+// typeName renders a type with an optional size and scale,
+// like VARCHAR(255) or DECIMAL(10, 2).
+func typeName(name string, size, scale int) string {
+	if size <= 0 {
+		return name
+	}
+	name += "(" + strconv.Itoa(size)
+	if scale > 0 {
+		name += ", " + strconv.Itoa(scale)
+	}
+	return name + ")"
+}
+
+// columnDef is a column definition of a CREATE TABLE or ALTER TABLE statement.
+type columnDef struct {
+	name       string
+	typ        ColumnType
+	primaryKey bool
+	notNull    bool
+	unique     bool
+	defaultVal string
+	references string
+	onDelete   string
+}
+
+// ColumnOption is a column constraint passed to Column and AddColumn methods.
+type ColumnOption func(d Dialect, col *columnDef)
+
+// PrimaryKey makes a column the primary key of a table.
+func PrimaryKey() ColumnOption {
+	return func(d Dialect, col *columnDef) {
+		col.primaryKey = true
+	}
+}
+
+// NotNull adds a NOT NULL constraint to a column.
+func NotNull() ColumnOption {
+	return func(d Dialect, col *columnDef) {
+		col.notNull = true
+	}
+}
+
+// UniqueColumn adds a UNIQUE constraint to a column.
+func UniqueColumn() ColumnOption {
+	return func(d Dialect, col *columnDef) {
+		col.unique = true
+	}
+}
+
+// Default sets a default value of a column.
+// expr is an SQL expression rendered as is, like 0 or CURRENT_TIMESTAMP.
+func Default(expr string) ColumnOption {
+	return func(d Dialect, col *columnDef) {
+		col.defaultVal = expr
+	}
+}
+
+// References adds a foreign key constraint to a column.
+func References(table string, columns ...string) ColumnOption {
+	return func(d Dialect, col *columnDef) {
+		col.references = quoteName(d, table) + " (" + quoteNames(d, columns) + ")"
+	}
+}
+
+// OnDelete adds an ON DELETE action like CASCADE or SET NULL
+// to a column foreign key constraint added by References.
+func OnDelete(action string) ColumnOption {
+	return func(d Dialect, col *columnDef) {
+		col.onDelete = action
+	}
+}
+
+// writeTo renders a column definition.
+func (col *columnDef) writeTo(b *strings.Builder, d Dialect) {
+	b.WriteString(quoteName(d, col.name))
+	b.WriteByte(' ')
+	b.WriteString(d.TypeName(col.typ))
+	if col.primaryKey {
+		b.WriteString(" PRIMARY KEY")
+	}
+	if col.notNull {
+		b.WriteString(" NOT NULL")
+	}
+	if col.unique {
+		b.WriteString(" UNIQUE")
+	}
+	if col.defaultVal != "" {
+		b.WriteString(" DEFAULT ")
+		b.WriteString(col.defaultVal)
+	}
+	if col.references != "" {
+		b.WriteString(" REFERENCES ")
+		b.WriteString(col.references)
+		if col.onDelete != "" {
+			b.WriteString(" ON DELETE ")
+			b.WriteString(col.onDelete)
+		}
+	}
+}
+
+// newColumn creates a column definition.
+func newColumn(d Dialect, name string, typ ColumnType, opts []ColumnOption) columnDef {
+	col := columnDef{name: name, typ: typ}
+	for _, opt := range opts {
+		opt(d, &col)
+	}
+	return col
+}
+
+/*
+CreateTableStmt is a CREATE TABLE statement builder.
+	q := sqlbuilder.CreateTable("users").
+		WithDialect(sqlbuilder.PostgreSQL).
+		Column("id", sqlbuilder.BigSerial, sqlbuilder.PrimaryKey()).
+		Column("email", sqlbuilder.Varchar(255), sqlbuilder.NotNull()).
+		Column("org_id", sqlbuilder.BigInt).
+		Unique("email").
+		ForeignKey([]string{"org_id"}, "orgs", "id").OnDelete("CASCADE")
+produces
+	CREATE TABLE users (id BIGSERIAL PRIMARY KEY, email VARCHAR(255) NOT NULL, org_id BIGINT, UNIQUE (email), FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE)
+*/
+type CreateTableStmt struct {
+	dialect     Dialect
+	name        string
+	ifNotExists bool
+	defs        []func(b *strings.Builder, d Dialect)
+	lastFK      *foreignKey
+}
+
+// foreignKey holds an ON DELETE action of a table foreign key constraint.
+type foreignKey struct {
+	onDelete string
+}
+
+// CreateTable starts a CREATE TABLE statement.
+// It uses the Dialect selected by SetDialect.
+func CreateTable(name string) *CreateTableStmt {
+	return &CreateTableStmt{dialect: defaultDialect(), name: name}
+}
+
+// WithDialect sets a Dialect to render the statement with.
+func (q *CreateTableStmt) WithDialect(d Dialect) *CreateTableStmt {
+	q.dialect = d
+	return q
+}
+
+// IfNotExists adds an IF NOT EXISTS clause to the statement.
+func (q *CreateTableStmt) IfNotExists() *CreateTableStmt {
+	q.ifNotExists = true
+	return q
+}
+
+// Column adds a column definition to the statement.
+func (q *CreateTableStmt) Column(name string, typ ColumnType, opts ...ColumnOption) *CreateTableStmt {
+	q.defs = append(q.defs, func(b *strings.Builder, d Dialect) {
+		col := newColumn(d, name, typ, opts)
+		col.writeTo(b, d)
+	})
+	return q
+}
+
+// PrimaryKey adds a primary key constraint on several columns.
+func (q *CreateTableStmt) PrimaryKey(columns ...string) *CreateTableStmt {
+	q.defs = append(q.defs, func(b *strings.Builder, d Dialect) {
+		b.WriteString("PRIMARY KEY (")
+		b.WriteString(quoteNames(d, columns))
+		b.WriteByte(')')
+	})
+	return q
+}
+
+// Unique adds a unique constraint on one or several columns.
+func (q *CreateTableStmt) Unique(columns ...string) *CreateTableStmt {
+	q.defs = append(q.defs, func(b *strings.Builder, d Dialect) {
+		b.WriteString("UNIQUE (")
+		b.WriteString(quoteNames(d, columns))
+		b.WriteByte(')')
+	})
+	return q
+}
+
+// ForeignKey adds a foreign key constraint.
+// Use OnDelete to set an action for the referencing rows.
+func (q *CreateTableStmt) ForeignKey(columns []string, refTable string, refColumns ...string) *CreateTableStmt {
+	fk := &foreignKey{}
+	q.lastFK = fk
+	q.defs = append(q.defs, func(b *strings.Builder, d Dialect) {
+		b.WriteString("FOREIGN KEY (")
+		b.WriteString(quoteNames(d, columns))
+		b.WriteString(") REFERENCES ")
+		b.WriteString(quoteName(d, refTable))
+		b.WriteString(" (")
+		b.WriteString(quoteNames(d, refColumns))
+		b.WriteByte(')')
+		if fk.onDelete != "" {
+			b.WriteString(" ON DELETE ")
+			b.WriteString(fk.onDelete)
+		}
+	})
+	return q
+}
+
+// OnDelete adds an ON DELETE action like CASCADE or SET NULL
+// to the foreign key constraint added last by ForeignKey.
+// The call is ignored if there is no such constraint,
+// use the OnDelete column option for References.
+func (q *CreateTableStmt) OnDelete(action string) *CreateTableStmt {
+	if q.lastFK != nil {
+		q.lastFK.onDelete = action
+	}
+	return q
+}
+
+// Check adds a CHECK constraint.
+func (q *CreateTableStmt) Check(expr string) *CreateTableStmt {
+	q.defs = append(q.defs, func(b *strings.Builder, d Dialect) {
+		b.WriteString("CHECK (")
+		b.WriteString(expr)
+		b.WriteByte(')')
+	})
+	return q
+}
+
+// String renders the statement.
+func (q *CreateTableStmt) String() string {
+	var b strings.Builder
+	b.WriteString("CREATE TABLE ")
+	if q.ifNotExists {
+		b.WriteString("IF NOT EXISTS ")
+	}
+	b.WriteString(quoteName(q.dialect, q.name))
+	b.WriteString(" (")
+	for n, def := range q.defs {
+		if n > 0 {
+			b.WriteString(", ")
+		}
+		def(&b, q.dialect)
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// Exec executes the statement.
+func (q *CreateTableStmt) Exec(ctx context.Context, db Executor) error {
+	return execDDL(ctx, db, q.String())
+}
+
+/*
+AlterTableStmt is an ALTER TABLE statement builder.
+Every action is rendered as a separate statement
+as not all databases can combine them:
+	q := sqlbuilder.AlterTable("users").
+		WithDialect(sqlbuilder.PostgreSQL).
+		AddColumn("age", sqlbuilder.Integer, sqlbuilder.Default("0")).
+		RenameColumn("email", "login")
+produces
+	ALTER TABLE users ADD COLUMN age INTEGER DEFAULT 0
+	ALTER TABLE users RENAME COLUMN email TO login
+*/
+type AlterTableStmt struct {
+	dialect Dialect
+	name    string
+	actions []func(b *strings.Builder, d Dialect, table string)
+}
+
+// AlterTable starts an ALTER TABLE statement.
+// It uses the Dialect selected by SetDialect.
+func AlterTable(name string) *AlterTableStmt {
+	return &AlterTableStmt{dialect: defaultDialect(), name: name}
+}
+
+// WithDialect sets a Dialect to render the statement with.
+func (q *AlterTableStmt) WithDialect(d Dialect) *AlterTableStmt {
+	q.dialect = d
+	return q
+}
+
+// AddColumn adds a column to a table.
+func (q *AlterTableStmt) AddColumn(name string, typ ColumnType, opts ...ColumnOption) *AlterTableStmt {
+	q.actions = append(q.actions, func(b *strings.Builder, d Dialect, table string) {
+		b.WriteString("ALTER TABLE ")
+		b.WriteString(table)
+		if d.Features()&FeatureAddColumn != 0 {
+			b.WriteString(" ADD COLUMN ")
+		} else {
+			b.WriteString(" ADD ")
+		}
+		col := newColumn(d, name, typ, opts)
+		col.writeTo(b, d)
+	})
+	return q
+}
+
+// DropColumn removes a column from a table.
+func (q *AlterTableStmt) DropColumn(name string) *AlterTableStmt {
+	q.actions = append(q.actions, func(b *strings.Builder, d Dialect, table string) {
+		b.WriteString("ALTER TABLE ")
+		b.WriteString(table)
+		b.WriteString(" DROP COLUMN ")
+		b.WriteString(quoteName(d, name))
+	})
+	return q
+}
+
+// RenameColumn renames a column of a table.
+// SQL Server columns are renamed with sp_rename procedure.
+func (q *AlterTableStmt) RenameColumn(oldName, newName string) *AlterTableStmt {
+	q.actions = append(q.actions, func(b *strings.Builder, d Dialect, table string) {
+		if d.Features()&FeatureRenameProc != 0 {
+			b.WriteString("EXEC sp_rename '")
+			b.WriteString(strings.Replace(q.name+"."+oldName, "'", "''", -1))
+			b.WriteString("', '")
+			b.WriteString(strings.Replace(newName, "'", "''", -1))
+			b.WriteString("', 'COLUMN'")
+			return
+		}
+		b.WriteString("ALTER TABLE ")
+		b.WriteString(table)
+		b.WriteString(" RENAME COLUMN ")
+		b.WriteString(quoteName(d, oldName))
+		b.WriteString(" TO ")
+		b.WriteString(quoteName(d, newName))
+	})
+	return q
+}
+
+// Statements renders a statement for every action.
+func (q *AlterTableStmt) Statements() []string {
+	table := quoteName(q.dialect, q.name)
+	stmts := make([]string, 0, len(q.actions))
+	for _, action := range q.actions {
+		var b strings.Builder
+		action(&b, q.dialect, table)
+		stmts = append(stmts, b.String())
+	}
+	return stmts
+}
+
+// String renders statements separated by semicolons.
+func (q *AlterTableStmt) String() string {
+	return strings.Join(q.Statements(), "; ")
+}
+
+// Exec executes statements one by one.
+func (q *AlterTableStmt) Exec(ctx context.Context, db Executor) error {
+	for _, s := range q.Statements() {
+		if err := execDDL(ctx, db, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+CreateIndexStmt is a CREATE INDEX statement builder.
+	q := sqlbuilder.CreateIndex("users_email_idx").
+		WithDialect(sqlbuilder.PostgreSQL).
+		Unique().
+		Concurrently().
+		On("users", "email").
+		Where("deleted_at IS NULL")
+produces
+	CREATE UNIQUE INDEX CONCURRENTLY users_email_idx ON users (email) WHERE deleted_at IS NULL
+*/
+type CreateIndexStmt struct {
+	dialect      Dialect
+	name         string
+	table        string
+	columns      []string
+	where        string
+	unique       bool
+	concurrently bool
+	ifNotExists  bool
+}
+
+// CreateIndex starts a CREATE INDEX statement.
+// It uses the Dialect selected by SetDialect.
+func CreateIndex(name string) *CreateIndexStmt {
+	return &CreateIndexStmt{dialect: defaultDialect(), name: name}
+}
+
+// WithDialect sets a Dialect to render the statement with.
+func (q *CreateIndexStmt) WithDialect(d Dialect) *CreateIndexStmt {
+	q.dialect = d
+	return q
+}
+
+// Unique makes the index unique.
+func (q *CreateIndexStmt) Unique() *CreateIndexStmt {
+	q.unique = true
+	return q
+}
+
+// IfNotExists adds an IF NOT EXISTS clause to the statement.
+func (q *CreateIndexStmt) IfNotExists() *CreateIndexStmt {
+	q.ifNotExists = true
+	return q
+}
+
+// Concurrently builds the index without locking writes to the table.
+// It's supported by PostgreSQL only and ignored with other dialects.
+func (q *CreateIndexStmt) Concurrently() *CreateIndexStmt {
+	q.concurrently = true
+	return q
+}
+
+// On sets a table and a list of columns to be indexed.
+// A column can be followed by ASC or DESC.
+// Expressions are rendered as is.
+func (q *CreateIndexStmt) On(table string, columns ...string) *CreateIndexStmt {
+	q.table = table
+	q.columns = columns
+	return q
+}
+
+// Where makes the index partial.
+// expr is an SQL expression rendered as is.
+func (q *CreateIndexStmt) Where(expr string) *CreateIndexStmt {
+	q.where = expr
+	return q
+}
+
+// String renders the statement.
+func (q *CreateIndexStmt) String() string {
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if q.unique {
+		b.WriteString("UNIQUE ")
+	}
+	b.WriteString("INDEX ")
+	if q.concurrently && q.dialect.Features()&FeatureConcurrentIndex != 0 {
+		b.WriteString("CONCURRENTLY ")
+	}
+	if q.ifNotExists {
+		b.WriteString("IF NOT EXISTS ")
+	}
+	b.WriteString(quoteName(q.dialect, q.name))
+	b.WriteString(" ON ")
+	b.WriteString(quoteName(q.dialect, q.table))
+	b.WriteString(" (")
+	for n, col := range q.columns {
+		if n > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(quoteIndexColumn(q.dialect, col))
+	}
+	b.WriteByte(')')
+	if q.where != "" {
+		b.WriteString(" WHERE ")
+		b.WriteString(q.where)
+	}
+	return b.String()
+}
+
+// Exec executes the statement.
+func (q *CreateIndexStmt) Exec(ctx context.Context, db Executor) error {
+	return execDDL(ctx, db, q.String())
+}
+
+// execDDL executes a DDL statement.
+func execDDL(ctx context.Context, db Executor, query string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+// reservedWords lists SQL keywords that are quoted when used as names.
+var reservedWords = map[string]bool{
+	"all": true, "alter": true, "and": true, "any": true, "as": true, "asc": true,
+	"between": true, "by": true, "case": true, "check": true, "column": true,
+	"constraint": true, "create": true, "cross": true, "current": true, "date": true,
+	"default": true, "delete": true, "desc": true, "distinct": true, "drop": true,
+	"else": true, "end": true, "exists": true, "fetch": true, "for": true,
+	"foreign": true, "from": true, "full": true, "grant": true, "group": true,
+	"having": true, "in": true, "index": true, "inner": true, "insert": true,
+	"into": true, "is": true, "join": true, "key": true, "left": true, "level": true,
+	"like": true, "limit": true, "not": true, "null": true, "number": true,
+	"offset": true, "on": true, "or": true, "order": true, "outer": true,
+	"primary": true, "references": true, "right": true, "rows": true,
+	"select": true, "session": true, "set": true, "size": true, "table": true,
+	"then": true, "to": true, "top": true, "union": true, "unique": true,
+	"update": true, "user": true, "using": true, "values": true, "when": true,
+	"where": true, "with": true,
+}
+
+// quoteName quotes parts of a dot separated name, like schema.table,
+// that are reserved words or start with a digit.
+// Other parts are left unquoted, so that database folds their case
+// the same way it does for names used by other statements.
+// Names that are not plain identifiers are returned as is.
+func quoteName(d Dialect, name string) string {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '_' && c != '.' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+			return name
+		}
+	}
+	parts := strings.Split(name, ".")
+	for n, part := range parts {
+		if part == "" || (part[0] >= '0' && part[0] <= '9') || reservedWords[strings.ToLower(part)] {
+			parts[n] = d.QuoteIdent(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteNames quotes a list of names and joins them with commas.
+func quoteNames(d Dialect, names []string) string {
+	quoted := make([]string, len(names))
+	for n, name := range names {
+		quoted[n] = quoteName(d, name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// quoteIndexColumn quotes an indexed column name keeping ASC or DESC suffix.
+func quoteIndexColumn(d Dialect, col string) string {
+	if k := strings.LastIndexByte(col, ' '); k > 0 {
+		switch strings.ToUpper(col[k+1:]) {
+		case "ASC", "DESC":
+			return quoteName(d, col[:k]) + col[k:]
+		}
+	}
+	return quoteName(d, col)
+}
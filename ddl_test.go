@@ -0,0 +1,88 @@
+package sqlbuilder_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sqlbuilder"
+)
+
+func TestCreateTable(t *testing.T) {
+	build := func(d sqlbuilder.Dialect) string {
+		return sqlbuilder.CreateTable("app.users").
+			WithDialect(d).
+			IfNotExists().
+			Column("id", sqlbuilder.BigSerial, sqlbuilder.PrimaryKey()).
+			Column("email", sqlbuilder.Varchar(255), sqlbuilder.NotNull()).
+			Column("balance", sqlbuilder.Decimal(10, 2), sqlbuilder.Default("0")).
+			Column("org_id", sqlbuilder.BigInt, sqlbuilder.References("orgs", "id")).
+			Unique("email").
+			ForeignKey([]string{"org_id"}, "orgs", "id").OnDelete("CASCADE").
+			String()
+	}
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS app.users (id BIGSERIAL PRIMARY KEY, email VARCHAR(255) NOT NULL, balance DECIMAL(10, 2) DEFAULT 0, org_id BIGINT REFERENCES orgs (id), UNIQUE (email), FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE)`, build(sqlbuilder.PostgreSQL))
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS app.users (id BIGINT AUTO_INCREMENT PRIMARY KEY, email VARCHAR(255) NOT NULL, balance DECIMAL(10, 2) DEFAULT 0, org_id BIGINT REFERENCES orgs (id), UNIQUE (email), FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE)", build(sqlbuilder.MySQL))
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS app.users (id BIGINT IDENTITY(1,1) PRIMARY KEY, email NVARCHAR(255) NOT NULL, balance DECIMAL(10, 2) DEFAULT 0, org_id BIGINT REFERENCES orgs (id), UNIQUE (email), FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE)", build(sqlbuilder.SQLServer))
+}
+
+func TestCreateTableQuoting(t *testing.T) {
+	build := func(d sqlbuilder.Dialect) string {
+		return sqlbuilder.CreateTable("Users").
+			WithDialect(d).
+			Column("id", sqlbuilder.BigInt).
+			Column("order", sqlbuilder.Integer).
+			Column("2fa", sqlbuilder.Boolean).
+			String()
+	}
+	assert.Equal(t, `CREATE TABLE Users (id BIGINT, "order" INTEGER, "2fa" BOOLEAN)`, build(sqlbuilder.PostgreSQL))
+	assert.Equal(t, "CREATE TABLE Users (id BIGINT, `order` INT, `2fa` BOOLEAN)", build(sqlbuilder.MySQL))
+	assert.Equal(t, "CREATE TABLE Users (id BIGINT, [order] INT, [2fa] BIT)", build(sqlbuilder.SQLServer))
+}
+
+func TestCreateTableOnDelete(t *testing.T) {
+	q := sqlbuilder.CreateTable("users").
+		WithDialect(sqlbuilder.PostgreSQL).
+		OnDelete("CASCADE").
+		Column("org_id", sqlbuilder.BigInt, sqlbuilder.References("orgs", "id"), sqlbuilder.OnDelete("SET NULL")).
+		Column("team_id", sqlbuilder.BigInt).
+		ForeignKey([]string{"team_id"}, "teams", "id").
+		Check("team_id > 0").
+		OnDelete("CASCADE")
+	assert.Equal(t, `CREATE TABLE users (org_id BIGINT REFERENCES orgs (id) ON DELETE SET NULL, team_id BIGINT, FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE, CHECK (team_id > 0))`, q.String())
+}
+
+func TestAlterTable(t *testing.T) {
+	build := func(d sqlbuilder.Dialect) []string {
+		return sqlbuilder.AlterTable("users").
+			WithDialect(d).
+			AddColumn("age", sqlbuilder.Integer, sqlbuilder.NotNull(), sqlbuilder.Default("0")).
+			DropColumn("nick").
+			RenameColumn("email", "login").
+			Statements()
+	}
+	assert.Equal(t, []string{
+		`ALTER TABLE users ADD COLUMN age INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE users DROP COLUMN nick`,
+		`ALTER TABLE users RENAME COLUMN email TO login`,
+	}, build(sqlbuilder.PostgreSQL))
+	assert.Equal(t, []string{
+		`ALTER TABLE users ADD age INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE users DROP COLUMN nick`,
+		`EXEC sp_rename 'users.email', 'login', 'COLUMN'`,
+	}, build(sqlbuilder.SQLServer))
+}
+
+func TestCreateIndex(t *testing.T) {
+	build := func(d sqlbuilder.Dialect) string {
+		return sqlbuilder.CreateIndex("users_email_idx").
+			WithDialect(d).
+			Unique().
+			Concurrently().
+			On("users", "email", "created_at DESC", "lower(name)").
+			Where("deleted_at IS NULL").
+			String()
+	}
+	assert.Equal(t, `CREATE UNIQUE INDEX CONCURRENTLY users_email_idx ON users (email, created_at DESC, lower(name)) WHERE deleted_at IS NULL`, build(sqlbuilder.PostgreSQL))
+	assert.Equal(t, `CREATE UNIQUE INDEX users_email_idx ON users (email, created_at DESC, lower(name)) WHERE deleted_at IS NULL`, build(sqlbuilder.SQLite))
+}
@@ -0,0 +1,102 @@
+package sqlbuilder
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+DebugString returns the statement SQL with arguments inlined as literals
+of the statement Dialect:
+	log.Printf("query failed: %v: %s", err, stmt.DebugString())
+produces
+	query failed: ...: SELECT id FROM users WHERE email = 'jane@example.com' AND created_at > '2020-01-02 03:04:05+00:00'
+Strings are quoted and escaped, byte slices are hex encoded,
+driver.Valuer values are converted with their Value method
+and nil values are rendered as NULL.
+Secret values are rendered as '***'.
+DebugString output is meant for logs and for running queries by hand only.
+Never execute it: values are not escaped the way a database driver does,
+so it is not safe against SQL injection.
+*/
+func (stmt *statement) DebugString() string {
+	s := stmt.String()
+	args := stmt.Args()
+	if len(args) == 0 {
+		return s
+	}
+	// Numbered placeholders are a prefix followed by an argument number
+	prefix := string(stmt.dialect.Placeholder(nil, 1))
+	numbered := isNumbered(stmt.dialect)
+	if numbered {
+		prefix = strings.TrimSuffix(prefix, "1")
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + len(args)*8)
+	src := []byte(s)
+	start, argNo := 0, 0
+	for pos := 0; pos < len(s); pos++ {
+		if end := skipLiteral(src, pos); end != pos {
+			// Placeholders are never inside literals or comments
+			pos = end
+			continue
+		}
+		switch c := s[pos]; {
+		case c == '\\' && strings.HasPrefix(s[pos:], `\?`):
+			// An escaped question mark
+			pos++
+		case numbered && strings.HasPrefix(s[pos:], prefix):
+			end := pos + len(prefix)
+			for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+				end++
+			}
+			n, err := strconv.Atoi(s[pos+len(prefix) : end])
+			if err != nil || n < 1 || n > len(args) {
+				continue
+			}
+			if _, ok := args[n-1].(sql.Out); ok {
+				continue
+			}
+			b.WriteString(s[start:pos])
+			b.WriteString(stmt.literal(n-1, args[n-1]))
+			start = end
+			pos = end - 1
+		case !numbered && c == '?' && argNo < len(args):
+			b.WriteString(s[start:pos])
+			b.WriteString(stmt.literal(argNo, args[argNo]))
+			argNo++
+			start = pos + 1
+		}
+	}
+	b.WriteString(s[start:])
+	return b.String()
+}
+
+// literal renders n-th argument as an SQL literal of the statement Dialect.
+// Secret arguments are redacted.
+func (stmt *statement) literal(n int, arg interface{}) string {
+	if stmt.isSecretArg(n) {
+		return redacted
+	}
+	if named, ok := arg.(sql.NamedArg); ok {
+		arg = named.Value
+	}
+	value, err := driver.DefaultParameterConverter.ConvertValue(arg)
+	if err != nil {
+		if _, ok := arg.(driver.Valuer); ok {
+			return "NULL /* " + strings.Replace(err.Error(), "*/", "* /", -1) + " */"
+		}
+		value = fmt.Sprint(arg)
+	}
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return stmt.dialect.Bool(v)
+	}
+	return stmt.dialect.Literal(value)
+}
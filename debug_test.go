@@ -0,0 +1,39 @@
+package sqlbuilder_test
+
+import (
+	"database/sql"
+	"sqlbuilder"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugString(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var name *string
+	stmt := sqlbuilder.WithDialect(sqlbuilder.PostgreSQL).
+		From("users").
+		Select("id").
+		Where("email = ? AND note <> '$1' AND created_at > ?", "o'neil@example.com", created).
+		Where("name = ? OR name IS NULL", name).
+		Where("is_active = ? AND avatar = ?", true, []byte{0xca, 0xfe}).
+		Where("score > ?", sql.NullFloat64{Float64: 1.5, Valid: true}).
+		Limit(10)
+	defer stmt.Close()
+	assert.Equal(t, "SELECT id FROM users WHERE email = 'o''neil@example.com' AND note <> '$1' AND created_at > '2020-01-02 03:04:05+00:00' AND "+
+		`name = NULL OR name IS NULL AND is_active = TRUE AND avatar = '\xcafe' AND score > 1.5 LIMIT 10`, stmt.DebugString())
+	assert.Equal(t, "SELECT id FROM users WHERE email = $1 AND note <> '$1' AND created_at > $2 AND "+
+		"name = $3 OR name IS NULL AND is_active = $4 AND avatar = $5 AND score > $6 LIMIT $7", stmt.String())
+
+	stmt = sqlbuilder.WithDialect(sqlbuilder.MySQL).
+		InsertInto("files").
+		Set("path", `C:\tmp`).
+		Set("data", []byte{1, 2})
+	defer stmt.Close()
+	assert.Equal(t, `INSERT INTO files (path, data) VALUES ('C:\\tmp', X'0102')`, stmt.DebugString())
+
+	stmt = sqlbuilder.WithDialect(sqlbuilder.SQLServer).From("users").Select("id").Where("is_active = ?", true).Limit(5)
+	defer stmt.Close()
+	assert.Equal(t, "SELECT TOP (5) id FROM users WHERE is_active = 1", stmt.DebugString())
+}
@@ -1,64 +1,708 @@
 package sqlbuilder
 
 import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
 	"strconv"
+	"strings"
 	"sync/atomic"
+	"time"
+)
+
+/*
+Dialect defines the method SQL statement is to be built.
+Use one of the built-in dialects:
+	sqlbuilder.DefaultDialect
+	sqlbuilder.PostgreSQL
+	sqlbuilder.MySQL
+	sqlbuilder.SQLServer
+	sqlbuilder.Oracle
+	sqlbuilder.SQLite
+To support another database implement the Dialect interface.
+Embed BaseDialect to override only the methods that differ:
+	type cockroach struct {
+		sqlbuilder.BaseDialect
+	}
+
+	func (cockroach) Placeholder(b []byte, argNo int) []byte {
+		return sqlbuilder.PostgreSQL.Placeholder(b, argNo)
+	}
+
+	func (cockroach) Upsert() sqlbuilder.UpsertStyle {
+		return sqlbuilder.UpsertOnConflict
+	}
+
+	func (d cockroach) Features() sqlbuilder.Feature {
+		return d.BaseDialect.Features() | sqlbuilder.FeatureArrays
+	}
+*/
+type Dialect interface {
+	// Placeholder appends a placeholder for argNo-th argument to b.
+	// Arguments are numbered starting from 1.
+	// Dialects using ? placeholders append ? regardless of argNo.
+	Placeholder(b []byte, argNo int) []byte
+
+	// QuoteIdent quotes an identifier.
+	// Quote characters inside the identifier are to be escaped.
+	QuoteIdent(ident string) string
+
+	// Pagination returns SQL fragments to be rendered in place of
+	// LIMIT and OFFSET clauses.
+	Pagination(hasLimit, hasOffset bool) Pagination
+
+	// Bool returns a boolean literal.
+	Bool(value bool) string
+
+	// Returning returns the way a RETURNING clause is supported.
+	// A RETURNING clause of a ReturningNone dialect statement
+	// is reported as an error by Err method.
+	Returning() ReturningStyle
 
-	"github.com/valyala/bytebufferpool"
+	// Upsert returns the kind of INSERT ... ON CONFLICT syntax supported.
+	// Upsert clauses of other kinds are reported as errors by Err method.
+	Upsert() UpsertStyle
+
+	// Reuse returns if a placeholder can be bound to several
+	// occurrences of the same argument.
+	Reuse() ReuseStyle
+
+	// MaxArgs returns the maximum number of arguments a statement can be bound to.
+	MaxArgs() int
+
+	// MultiTable returns a syntax of UPDATE and DELETE statements
+	// involving several tables.
+	MultiTable() MultiTableStyle
+
+	// TypeName returns a name of a column type to be used in DDL statements.
+	TypeName(t ColumnType) string
+
+	// Literal renders a string, int64, float64, []byte or time.Time value
+	// as an SQL literal for DebugString output.
+	Literal(value driver.Value) string
+
+	// Features returns a set of optional features the database supports.
+	Features() Feature
+}
+
+// Pagination holds SQL fragments to be rendered in place of LIMIT and OFFSET.
+// Each fragment contains a single ? placeholder at most.
+// Empty fragments are omitted.
+type Pagination struct {
+	// Top is rendered right after the SELECT, UPDATE or DELETE verb
+	Top    string
+	Limit  string
+	Offset string
+	// OffsetFirst is set if Offset fragment goes before the Limit one
+	OffsetFirst bool
+}
+
+// ReturningStyle defines how a RETURNING clause is to be rendered.
+type ReturningStyle int
+
+const (
+	// ReturningClause renders RETURNING clause as is.
+	ReturningClause ReturningStyle = iota
+	// ReturningInto renders RETURNING ... INTO clause with out binds
+	// for value pointers passed to To method.
+	ReturningInto
+	// ReturningNone stands for databases that don't support RETURNING.
+	ReturningNone
 )
 
-// Dialect defines the method SQL statement is to be built.
-type Dialect uint32
+// UpsertStyle defines a syntax to update a row on INSERT conflicts.
+type UpsertStyle int
 
 const (
+	// UpsertNone stands for databases that have no upsert syntax.
+	UpsertNone UpsertStyle = iota
+	// UpsertOnConflict stands for INSERT ... ON CONFLICT syntax.
+	UpsertOnConflict
+	// UpsertOnDuplicateKey stands for INSERT ... ON DUPLICATE KEY UPDATE syntax.
+	UpsertOnDuplicateKey
+	// UpsertAny renders both ON CONFLICT and ON DUPLICATE KEY UPDATE clauses as is.
+	UpsertAny
+)
+
+// ReuseStyle defines if numbered placeholders can be reused in a statement.
+type ReuseStyle int
+
+const (
+	// ReuseNone binds every placeholder to its own argument.
+	ReuseNone ReuseStyle = iota
+	// ReuseNamed binds every named parameter once and reuses its placeholder.
+	ReuseNamed
+	// ReuseEqual binds equal arguments once and reuses their placeholder.
+	// It implies ReuseNamed.
+	ReuseEqual
+)
+
+// Feature is a set of optional database features.
+type Feature uint32
+
+const (
+	// FeatureArrays allows lists to be bound as a single array: = ANY(?).
+	// See SetArrayThreshold.
+	FeatureArrays Feature = 1 << iota
+	// FeatureNullsOrder stands for NULLS FIRST and NULLS LAST support
+	// in ORDER BY clauses. OrderBySafe emulates them otherwise.
+	FeatureNullsOrder
+	// FeatureMergeTerminator requires MERGE statements to end with a semicolon.
+	FeatureMergeTerminator
+	// FeatureAddColumn stands for ALTER TABLE ... ADD COLUMN syntax
+	// instead of ALTER TABLE ... ADD one.
+	FeatureAddColumn
+	// FeatureRenameProc makes columns be renamed with sp_rename procedure
+	// instead of ALTER TABLE ... RENAME COLUMN statement.
+	FeatureRenameProc
+	// FeatureConcurrentIndex stands for CREATE INDEX CONCURRENTLY support.
+	FeatureConcurrentIndex
+)
+
+// MultiTableStyle defines how tables added by UpdateFrom
+// and DeleteUsing are rendered.
+type MultiTableStyle int
+
+const (
+	// MultiTableUsing renders UPDATE t SET ... FROM x and DELETE FROM t USING x.
+	MultiTableUsing MultiTableStyle = iota
+	// MultiTableList renders UPDATE t, x SET ... and DELETE t FROM t, x.
+	MultiTableList
+	// MultiTableFrom renders UPDATE t SET ... FROM x and DELETE t FROM t, x.
+	MultiTableFrom
+)
+
+var (
 	// DefaultDialect is a default statement builder mode.
-	DefaultDialect Dialect = iota
+	DefaultDialect Dialect = BaseDialect{}
 	// PostgreSQL dialect is to be used to automatically replace ? placeholders with $1, $2...
-	PostgreSQL
+	PostgreSQL Dialect = postgresqlDialect{}
+	// MySQL dialect quotes identifiers with backticks and always renders
+	// OFFSET together with LIMIT as MySQL requires.
+	MySQL Dialect = mysqlDialect{}
+	// SQLServer dialect replaces ? placeholders with @p1, @p2...
+	// and renders LIMIT and OFFSET as TOP or OFFSET ... FETCH NEXT clauses.
+	SQLServer Dialect = sqlServerDialect{}
+	// Oracle dialect replaces ? placeholders with :1, :2...,
+	// renders LIMIT and OFFSET as OFFSET ... FETCH FIRST clauses (Oracle 12c+)
+	// and returns values of a RETURNING clause via out binds.
+	Oracle Dialect = oracleDialect{}
+	// SQLite dialect replaces ? placeholders with ?1, ?2...
+	// Equal arguments are bound once and share a placeholder.
+	// RETURNING clause requires SQLite 3.35 or newer.
+	SQLite Dialect = sqliteDialect{}
 )
 
-var selectedDialect = DefaultDialect
+// dialectValue wraps a Dialect for it to be stored in atomic.Value
+// as the latter requires all the values to be of the same type.
+type dialectValue struct {
+	Dialect
+}
+
+var selectedDialect atomic.Value
+
+func init() {
+	selectedDialect.Store(dialectValue{DefaultDialect})
+}
 
 // SetDialect selects a Dialect to be used by default.
 func SetDialect(dialect Dialect) {
-	atomic.StoreUint32((*uint32)(&selectedDialect), uint32(dialect))
+	selectedDialect.Store(dialectValue{dialect})
 }
 
-// writePostgresql function copies s into buf and replaces ? placeholders with $1, $2...
-func writePostgresql(argNo int, s []byte, buf *bytebufferpool.ByteBuffer) (int, error) {
-	var err error
-	start := 0
-	// Iterate by runes
-	for pos, r := range bufferToString(&s) {
-		if start > pos {
-			continue
-		}
-		switch r {
-		case '\\':
-			if pos < len(s)-1 && s[pos+1] == '?' {
-				_, err = buf.Write(s[start:pos])
-				if err == nil {
-					err = buf.WriteByte('?')
-				}
-				start = pos + 2
-			}
-		case '?':
-			_, err = buf.Write(s[start:pos])
-			start = pos + 1
-			if err == nil {
-				err = buf.WriteByte('$')
-				if err == nil {
-					buf.B = strconv.AppendInt(buf.B, int64(argNo), 10)
-					argNo++
-				}
-			}
-		}
-		if err != nil {
-			break
+// defaultDialect returns a Dialect selected by SetDialect.
+func defaultDialect() Dialect {
+	return selectedDialect.Load().(dialectValue).Dialect
+}
+
+// BaseDialect implements a Dialect with ? placeholders, double quoted
+// identifiers and LIMIT ... OFFSET ... pagination.
+// Embed it to implement a custom Dialect.
+type BaseDialect struct{}
+
+// Placeholder appends a ? placeholder to b.
+func (BaseDialect) Placeholder(b []byte, argNo int) []byte {
+	return append(b, '?')
+}
+
+// QuoteIdent quotes an identifier with double quotes.
+func (BaseDialect) QuoteIdent(ident string) string {
+	return quoteIdent(ident, "\"", "\"")
+}
+
+// Pagination returns LIMIT ? and OFFSET ? fragments.
+func (BaseDialect) Pagination(hasLimit, hasOffset bool) (pg Pagination) {
+	if hasLimit {
+		pg.Limit = "LIMIT ?"
+	}
+	if hasOffset {
+		pg.Offset = "OFFSET ?"
+	}
+	return pg
+}
+
+// Bool returns TRUE or FALSE.
+func (BaseDialect) Bool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// Returning returns ReturningClause.
+func (BaseDialect) Returning() ReturningStyle {
+	return ReturningClause
+}
+
+// Upsert returns UpsertAny, so upsert clauses are rendered as is
+// like a RETURNING clause is.
+func (BaseDialect) Upsert() UpsertStyle {
+	return UpsertAny
+}
+
+// Reuse returns ReuseNone.
+func (BaseDialect) Reuse() ReuseStyle {
+	return ReuseNone
+}
+
+// MaxArgs returns 999, a conservative limit most databases accept.
+func (BaseDialect) MaxArgs() int {
+	return 999
+}
+
+// MultiTable returns MultiTableUsing.
+func (BaseDialect) MultiTable() MultiTableStyle {
+	return MultiTableUsing
+}
+
+// TypeName returns an SQL standard type name.
+func (BaseDialect) TypeName(t ColumnType) string {
+	switch t.Kind {
+	case TypeSmallInt:
+		return "SMALLINT"
+	case TypeInteger:
+		return "INTEGER"
+	case TypeBigInt:
+		return "BIGINT"
+	case TypeSerial:
+		return "INTEGER GENERATED BY DEFAULT AS IDENTITY"
+	case TypeBigSerial:
+		return "BIGINT GENERATED BY DEFAULT AS IDENTITY"
+	case TypeBoolean:
+		return "BOOLEAN"
+	case TypeDouble:
+		return "DOUBLE PRECISION"
+	case TypeDecimal:
+		return typeName("DECIMAL", t.Size, t.Scale)
+	case TypeVarchar:
+		return typeName("VARCHAR", t.Size, 0)
+	case TypeText, TypeJSON:
+		return "TEXT"
+	case TypeBytes:
+		return "BLOB"
+	case TypeDate:
+		return "DATE"
+	case TypeTimestamp:
+		return "TIMESTAMP"
+	case TypeTimestampTZ:
+		return "TIMESTAMP WITH TIME ZONE"
+	case TypeUUID:
+		return "CHAR(36)"
+	}
+	return t.Name
+}
+
+// Literal renders a value as an SQL standard literal.
+func (BaseDialect) Literal(value driver.Value) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []byte:
+		return "X'" + hex.EncodeToString(v) + "'"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999999-07:00") + "'"
+	case string:
+		return quoteString(v)
+	}
+	return quoteString(fmt.Sprint(value))
+}
+
+// Features returns FeatureNullsOrder and FeatureAddColumn.
+func (BaseDialect) Features() Feature {
+	return FeatureNullsOrder | FeatureAddColumn
+}
+
+type postgresqlDialect struct {
+	BaseDialect
+}
+
+func (postgresqlDialect) Placeholder(b []byte, argNo int) []byte {
+	return strconv.AppendInt(append(b, '$'), int64(argNo), 10)
+}
+
+func (postgresqlDialect) Upsert() UpsertStyle {
+	return UpsertOnConflict
+}
+
+func (postgresqlDialect) Reuse() ReuseStyle {
+	return ReuseNamed
+}
+
+func (postgresqlDialect) MaxArgs() int {
+	return 65535
+}
+
+func (d postgresqlDialect) Literal(value driver.Value) string {
+	if v, ok := value.([]byte); ok {
+		return `'\x` + hex.EncodeToString(v) + "'"
+	}
+	return d.BaseDialect.Literal(value)
+}
+
+func (postgresqlDialect) Features() Feature {
+	return FeatureArrays | FeatureNullsOrder | FeatureAddColumn | FeatureConcurrentIndex
+}
+
+func (d postgresqlDialect) TypeName(t ColumnType) string {
+	switch t.Kind {
+	case TypeSerial:
+		return "SERIAL"
+	case TypeBigSerial:
+		return "BIGSERIAL"
+	case TypeBytes:
+		return "BYTEA"
+	case TypeTimestampTZ:
+		return "TIMESTAMPTZ"
+	case TypeUUID:
+		return "UUID"
+	case TypeJSON:
+		return "JSONB"
+	}
+	return d.BaseDialect.TypeName(t)
+}
+
+// mysqlMaxLimit is the largest LIMIT value accepted by MySQL.
+// It is used to render OFFSET without LIMIT.
+const mysqlMaxLimit = "LIMIT 18446744073709551615"
+
+type mysqlDialect struct {
+	BaseDialect
+}
+
+func (mysqlDialect) QuoteIdent(ident string) string {
+	return quoteIdent(ident, "`", "`")
+}
+
+func (d mysqlDialect) Pagination(hasLimit, hasOffset bool) Pagination {
+	pg := d.BaseDialect.Pagination(hasLimit, hasOffset)
+	if hasOffset && !hasLimit {
+		// MySQL does not accept OFFSET without LIMIT
+		pg.Limit = mysqlMaxLimit
+	}
+	return pg
+}
+
+func (mysqlDialect) Returning() ReturningStyle {
+	return ReturningNone
+}
+
+func (mysqlDialect) Upsert() UpsertStyle {
+	return UpsertOnDuplicateKey
+}
+
+func (mysqlDialect) MaxArgs() int {
+	return 65535
+}
+
+func (mysqlDialect) MultiTable() MultiTableStyle {
+	return MultiTableList
+}
+
+func (d mysqlDialect) Literal(value driver.Value) string {
+	switch v := value.(type) {
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999") + "'"
+	case string:
+		// MySQL treats backslashes as escape characters
+		return quoteString(strings.Replace(v, `\`, `\\`, -1))
+	}
+	return d.BaseDialect.Literal(value)
+}
+
+func (mysqlDialect) Features() Feature {
+	return FeatureAddColumn
+}
+
+func (d mysqlDialect) TypeName(t ColumnType) string {
+	switch t.Kind {
+	case TypeInteger:
+		return "INT"
+	case TypeSerial:
+		return "INT AUTO_INCREMENT"
+	case TypeBigSerial:
+		return "BIGINT AUTO_INCREMENT"
+	case TypeDouble:
+		return "DOUBLE"
+	case TypeBytes:
+		return "LONGBLOB"
+	case TypeText:
+		return "LONGTEXT"
+	case TypeTimestamp:
+		return "DATETIME"
+	case TypeTimestampTZ:
+		return "TIMESTAMP"
+	case TypeJSON:
+		return "JSON"
+	}
+	return d.BaseDialect.TypeName(t)
+}
+
+type sqlServerDialect struct {
+	BaseDialect
+}
+
+func (sqlServerDialect) Placeholder(b []byte, argNo int) []byte {
+	return strconv.AppendInt(append(b, '@', 'p'), int64(argNo), 10)
+}
+
+func (sqlServerDialect) QuoteIdent(ident string) string {
+	return quoteIdent(ident, "[", "]")
+}
+
+func (sqlServerDialect) Pagination(hasLimit, hasOffset bool) (pg Pagination) {
+	if !hasOffset {
+		if hasLimit {
+			pg.Top = "TOP (?)"
 		}
+		return pg
+	}
+	pg.Offset = "OFFSET ? ROWS"
+	pg.OffsetFirst = true
+	if hasLimit {
+		pg.Limit = "FETCH NEXT ? ROWS ONLY"
+	}
+	return pg
+}
+
+func (sqlServerDialect) Bool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func (sqlServerDialect) Returning() ReturningStyle {
+	return ReturningNone
+}
+
+func (sqlServerDialect) Upsert() UpsertStyle {
+	return UpsertNone
+}
+
+func (sqlServerDialect) Reuse() ReuseStyle {
+	return ReuseNamed
+}
+
+// MaxArgs stays 2 below the limit of 2100 parameters,
+// as sp_executesql used by drivers counts its own ones too.
+func (sqlServerDialect) MaxArgs() int {
+	return 2098
+}
+
+func (sqlServerDialect) MultiTable() MultiTableStyle {
+	return MultiTableFrom
+}
+
+func (d sqlServerDialect) Literal(value driver.Value) string {
+	if v, ok := value.([]byte); ok {
+		return "0x" + hex.EncodeToString(v)
+	}
+	return d.BaseDialect.Literal(value)
+}
+
+func (sqlServerDialect) Features() Feature {
+	return FeatureMergeTerminator | FeatureRenameProc
+}
+
+func (d sqlServerDialect) TypeName(t ColumnType) string {
+	switch t.Kind {
+	case TypeInteger:
+		return "INT"
+	case TypeSerial:
+		return "INT IDENTITY(1,1)"
+	case TypeBigSerial:
+		return "BIGINT IDENTITY(1,1)"
+	case TypeBoolean:
+		return "BIT"
+	case TypeDouble:
+		return "FLOAT"
+	case TypeVarchar:
+		return typeName("NVARCHAR", t.Size, 0)
+	case TypeText, TypeJSON:
+		return "NVARCHAR(MAX)"
+	case TypeBytes:
+		return "VARBINARY(MAX)"
+	case TypeTimestamp:
+		return "DATETIME2"
+	case TypeTimestampTZ:
+		return "DATETIMEOFFSET"
+	case TypeUUID:
+		return "UNIQUEIDENTIFIER"
 	}
-	if err == nil && start < len(s) {
-		_, err = buf.Write(s[start:])
+	return d.BaseDialect.TypeName(t)
+}
+
+type oracleDialect struct {
+	BaseDialect
+}
+
+func (oracleDialect) Placeholder(b []byte, argNo int) []byte {
+	return strconv.AppendInt(append(b, ':'), int64(argNo), 10)
+}
+
+func (oracleDialect) Pagination(hasLimit, hasOffset bool) (pg Pagination) {
+	if hasOffset {
+		pg.Offset = "OFFSET ? ROWS"
+	}
+	if hasLimit {
+		pg.Limit = "FETCH FIRST ? ROWS ONLY"
 	}
-	return argNo, err
+	pg.OffsetFirst = true
+	return pg
+}
+
+func (oracleDialect) Bool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func (oracleDialect) Returning() ReturningStyle {
+	return ReturningInto
+}
+
+func (oracleDialect) Upsert() UpsertStyle {
+	return UpsertNone
+}
+
+func (oracleDialect) MaxArgs() int {
+	return 65535
+}
+
+func (d oracleDialect) Literal(value driver.Value) string {
+	switch v := value.(type) {
+	case []byte:
+		return "HEXTORAW('" + hex.EncodeToString(v) + "')"
+	case time.Time:
+		return "TIMESTAMP '" + v.Format("2006-01-02 15:04:05.999999999 -07:00") + "'"
+	}
+	return d.BaseDialect.Literal(value)
+}
+
+func (oracleDialect) Features() Feature {
+	return FeatureNullsOrder
+}
+
+func (d oracleDialect) TypeName(t ColumnType) string {
+	switch t.Kind {
+	case TypeSmallInt:
+		return "NUMBER(5)"
+	case TypeInteger:
+		return "NUMBER(10)"
+	case TypeBigInt:
+		return "NUMBER(19)"
+	case TypeSerial:
+		return "NUMBER(10) GENERATED BY DEFAULT AS IDENTITY"
+	case TypeBigSerial:
+		return "NUMBER(19) GENERATED BY DEFAULT AS IDENTITY"
+	case TypeBoolean:
+		return "NUMBER(1)"
+	case TypeDouble:
+		return "BINARY_DOUBLE"
+	case TypeDecimal:
+		return typeName("NUMBER", t.Size, t.Scale)
+	case TypeVarchar:
+		return typeName("VARCHAR2", t.Size, 0)
+	case TypeText, TypeJSON:
+		return "CLOB"
+	case TypeUUID:
+		return "RAW(16)"
+	}
+	return d.BaseDialect.TypeName(t)
+}
+
+type sqliteDialect struct {
+	BaseDialect
+}
+
+func (sqliteDialect) Placeholder(b []byte, argNo int) []byte {
+	return strconv.AppendInt(append(b, '?'), int64(argNo), 10)
+}
+
+func (d sqliteDialect) Pagination(hasLimit, hasOffset bool) Pagination {
+	pg := d.BaseDialect.Pagination(hasLimit, hasOffset)
+	if hasOffset && !hasLimit {
+		// SQLite does not accept OFFSET without LIMIT
+		pg.Limit = "LIMIT -1"
+	}
+	return pg
+}
+
+func (sqliteDialect) Bool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func (sqliteDialect) Upsert() UpsertStyle {
+	return UpsertOnConflict
+}
+
+func (sqliteDialect) Reuse() ReuseStyle {
+	return ReuseEqual
+}
+
+// MaxArgs returns the limit of SQLite versions prior to 3.32.0.
+// Newer versions accept up to 32766 arguments.
+func (sqliteDialect) MaxArgs() int {
+	return 999
+}
+
+// TypeName returns names of SQLite type affinities.
+// Serial columns are to be declared as INTEGER PRIMARY KEY
+// to become an alias of ROWID.
+func (d sqliteDialect) TypeName(t ColumnType) string {
+	switch t.Kind {
+	case TypeSmallInt, TypeInteger, TypeBigInt, TypeSerial, TypeBigSerial, TypeBoolean:
+		return "INTEGER"
+	case TypeDouble:
+		return "REAL"
+	case TypeDecimal:
+		return "NUMERIC"
+	case TypeVarchar, TypeText, TypeUUID, TypeJSON:
+		return "TEXT"
+	}
+	return d.BaseDialect.TypeName(t)
+}
+
+// quoteIdent wraps ident into opening and closing quotes
+// and escapes closing quote characters by doubling them.
+func quoteIdent(ident, openQuote, closeQuote string) string {
+	return openQuote + strings.Replace(ident, closeQuote, closeQuote+closeQuote, -1) + closeQuote
+}
+
+// quoteString renders a string literal.
+func quoteString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// isNumbered reports if a dialect uses numbered placeholders like $1, $2...
+func isNumbered(d Dialect) bool {
+	var b [4]byte
+	p := d.Placeholder(b[:0], 1)
+	return len(p) != 1 || p[0] != '?'
 }
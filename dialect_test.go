@@ -0,0 +1,61 @@
+package sqlbuilder_test
+
+import (
+	"sqlbuilder"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type namedDialect struct {
+	sqlbuilder.BaseDialect
+}
+
+func (namedDialect) Placeholder(b []byte, argNo int) []byte {
+	return strconv.AppendInt(append(b, ":arg"...), int64(argNo), 10)
+}
+
+func (namedDialect) Pagination(hasLimit, hasOffset bool) (pg sqlbuilder.Pagination) {
+	if hasLimit {
+		pg.Limit = "FIRST ?"
+	}
+	if hasOffset {
+		pg.Offset = "SKIP ?"
+	}
+	pg.OffsetFirst = true
+	return pg
+}
+
+func TestCustomDialect(t *testing.T) {
+	q := sqlbuilder.WithDialect(namedDialect{}).
+		From("items").
+		Select("id").
+		Where("price > ?", 10).
+		Paginate(2, 5)
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM items WHERE price > :arg1 SKIP :arg2 FIRST :arg3", q.String())
+	assert.Equal(t, []interface{}{10, 5, 5}, q.Args())
+}
+
+func TestSetDialect(t *testing.T) {
+	sqlbuilder.SetDialect(sqlbuilder.PostgreSQL)
+	defer sqlbuilder.SetDialect(sqlbuilder.DefaultDialect)
+
+	q := sqlbuilder.From("items").Select("id").Where("id = ?", 42)
+	defer q.Close()
+	assert.Equal(t, sqlbuilder.PostgreSQL, q.GetDialect())
+	assert.Equal(t, "SELECT id FROM items WHERE id = $1", q.String())
+
+	q.SetDialect(sqlbuilder.SQLServer)
+	assert.Equal(t, "SELECT id FROM items WHERE id = @p1", q.String())
+}
+
+func TestDialectQuoteIdent(t *testing.T) {
+	assert.Equal(t, "`order`", sqlbuilder.MySQL.QuoteIdent("order"))
+	assert.Equal(t, "`a``b`", sqlbuilder.MySQL.QuoteIdent("a`b"))
+	assert.Equal(t, `"order"`, sqlbuilder.PostgreSQL.QuoteIdent("order"))
+	assert.Equal(t, `"a""b"`, sqlbuilder.DefaultDialect.QuoteIdent(`a"b`))
+	assert.Equal(t, "[a]]b]", sqlbuilder.SQLServer.QuoteIdent("a]b"))
+	assert.Equal(t, `"order"`, sqlbuilder.Oracle.QuoteIdent("order"))
+}
@@ -0,0 +1,59 @@
+package sqlbuilder
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// strictMode is set to 1 if construction errors are to panic.
+var strictMode int32
+
+/*
+SetStrict makes statement builders panic on construction errors,
+like a Set call made before InsertInto or Update,
+and on errors found on statement build, like a mismatch of placeholders
+and arguments or a clause not supported by the statement Dialect,
+instead of returning them from Err, Query, QueryRow and Exec methods.
+It is meant to catch builder misuse in tests:
+	func TestMain(m *testing.M) {
+		sqlbuilder.SetStrict(true)
+		os.Exit(m.Run())
+	}
+*/
+func SetStrict(strict bool) {
+	var v int32
+	if strict {
+		v = 1
+	}
+	atomic.StoreInt32(&strictMode, v)
+}
+
+// errorList holds several errors of a statement.
+type errorList []error
+
+func (errs errorList) Error() string {
+	msgs := make([]string, len(errs))
+	for n, err := range errs {
+		msgs[n] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// fail records a construction error of a statement.
+func (stmt *statement) fail(err error) {
+	if atomic.LoadInt32(&strictMode) == 1 {
+		panic(err)
+	}
+	stmt.errs = append(stmt.errs, err)
+}
+
+// failBuild records the first error found on statement build.
+func (stmt *statement) failBuild(err error) {
+	if stmt.buildErr != nil {
+		return
+	}
+	stmt.buildErr = err
+	if atomic.LoadInt32(&strictMode) == 1 {
+		panic(err)
+	}
+}
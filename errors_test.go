@@ -0,0 +1,96 @@
+package sqlbuilder_test
+
+import (
+	"context"
+	"sqlbuilder"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErr(t *testing.T) {
+	stmt := sqlbuilder.From("users").Select("id").Where("id = ?", 1)
+	defer stmt.Close()
+	assert.NoError(t, stmt.Err())
+
+	stmt = sqlbuilder.From("users").Set("name", "Jane")
+	defer stmt.Close()
+	assert.EqualError(t, stmt.Err(), `sqlbuilder: Set("name") requires an INSERT, UPDATE or upsert clause`)
+
+	stmt = sqlbuilder.From("users").Select("id").OrderBy("id").In(1, 2).ThenDelete()
+	defer stmt.Close()
+	assert.EqualError(t, stmt.Err(), "sqlbuilder: In must follow a Where call; "+
+		"sqlbuilder: ThenDelete requires a preceding WhenMatched or WhenNotMatched call")
+
+	stmt = sqlbuilder.MergeInto("t").Using("s", "s", "s.id = t.id")
+	defer stmt.Close()
+	for n := 0; n < 11; n++ {
+		stmt.WhenMatched(sqlbuilder.Raw("s.k = ?", n)).ThenDelete()
+	}
+	assert.EqualError(t, stmt.Err(), "sqlbuilder: a MERGE statement can have up to 10 WHEN branches")
+
+	stmt = sqlbuilder.MergeInto("t").Using("s", "s", "s.id = t.id").WhenMatched().ThenDelete().SetExpr("a", "1")
+	defer stmt.Close()
+	assert.EqualError(t, stmt.Err(), `sqlbuilder: Set("a") can not follow ThenDelete`)
+
+	stmt = sqlbuilder.MergeInto("t").Using("s", "s", "s.id = t.id").WhenMatched().Set("a", 1)
+	defer stmt.Close()
+	assert.EqualError(t, stmt.Err(), `sqlbuilder: Set("a") requires a ThenUpdate or ThenInsert call; `+
+		"sqlbuilder: a WHEN branch of a MERGE statement requires a ThenUpdate, ThenDelete or ThenInsert call")
+
+	stmt = sqlbuilder.MergeInto("t").Using("s", "s", "s.id = t.id").WhenMatched().ThenDelete().WhenNotMatched()
+	defer stmt.Close()
+	assert.EqualError(t, stmt.Err(), "sqlbuilder: a WHEN branch of a MERGE statement requires a ThenUpdate, ThenDelete or ThenInsert call")
+
+	stmt = sqlbuilder.WithDialect(sqlbuilder.PostgreSQL).From("users").Select("id").Where("id = ? OR id = ?", 1)
+	defer stmt.Close()
+	assert.EqualError(t, stmt.Err(), `sqlbuilder: "WHERE id = ? OR id = ?" expects 2 arguments, got 1`)
+}
+
+func TestErrUnsupported(t *testing.T) {
+	stmt := sqlbuilder.WithDialect(sqlbuilder.MySQL).InsertInto("t").Set("a", 1).OnConflict("a").DoNothing()
+	defer stmt.Close()
+	assert.EqualError(t, stmt.Err(), "sqlbuilder: ON CONFLICT clause is not supported by the dialect")
+
+	stmt = sqlbuilder.UsingPostgresql().InsertInto("t").Set("a", 1).OnDuplicateKeyUpdate().Set("a", 2)
+	defer stmt.Close()
+	assert.EqualError(t, stmt.Err(), "sqlbuilder: ON DUPLICATE KEY UPDATE clause is not supported by the dialect")
+
+	stmt = sqlbuilder.WithDialect(sqlbuilder.SQLServer).InsertInto("t").Set("a", 1).Returning("id")
+	defer stmt.Close()
+	assert.EqualError(t, stmt.Err(), "sqlbuilder: RETURNING clause is not supported by the dialect")
+
+	stmt = sqlbuilder.WithDialect(sqlbuilder.Oracle).InsertInto("t").Set("a", 1).OnConflict("a").DoNothing()
+	defer stmt.Close()
+	assert.EqualError(t, stmt.Err(), "sqlbuilder: ON CONFLICT clause is not supported by the dialect")
+
+	// DefaultDialect renders upsert clauses as is, like RETURNING
+	stmt = sqlbuilder.InsertInto("t").Set("a", 1).OnDuplicateKeyUpdate().Set("a", 2).Returning("id")
+	defer stmt.Close()
+	assert.NoError(t, stmt.Err())
+	assert.Equal(t, "INSERT INTO t (a) VALUES (?) ON DUPLICATE KEY UPDATE a=? RETURNING id", stmt.String())
+
+	stmt = sqlbuilder.InsertInto("t").Set("a", 1).OnConflict("a").DoNothing()
+	defer stmt.Close()
+	assert.NoError(t, stmt.Err())
+}
+
+func TestErrOnExec(t *testing.T) {
+	fake := &fakeDB{}
+	db := fake.open()
+	defer db.Close()
+
+	err := sqlbuilder.From("users").Select("id").Values(1).QueryAndClose(context.Background(), db, nil)
+	assert.EqualError(t, err, "sqlbuilder: Values requires an INSERT clause")
+	_, err = sqlbuilder.Update("users").SetStruct(42).ExecAndClose(context.Background(), db)
+	assert.EqualError(t, err, "sqlbuilder: SetStruct expects a struct, got int")
+	assert.Empty(t, fake.queries)
+}
+
+func TestStrict(t *testing.T) {
+	sqlbuilder.SetStrict(true)
+	defer sqlbuilder.SetStrict(false)
+	assert.Panics(t, func() {
+		sqlbuilder.From("users").Set("name", "Jane")
+	})
+}
@@ -13,3 +13,19 @@ type Executor interface {
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
+
+// StatementLogger is an optional interface an Executor can implement
+// to log statements. Query, QueryRow and Exec methods call LogStatement
+// right before a statement is executed.
+// Use Statement.DebugString to log a statement with its arguments.
+// Secret values are redacted in its output.
+type StatementLogger interface {
+	LogStatement(ctx context.Context, stmt Statement)
+}
+
+// logStatement passes a statement to an Executor implementing StatementLogger.
+func logStatement(ctx context.Context, db Executor, stmt Statement) {
+	if logger, ok := db.(StatementLogger); ok {
+		logger.LogStatement(ctx, stmt)
+	}
+}
@@ -0,0 +1,92 @@
+package sqlbuilder_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+)
+
+// fakeDB is a database/sql driver returning a fixed result set for any query.
+type fakeDB struct {
+	columns []string
+	rows    [][]driver.Value
+	queries []string
+}
+
+// open returns an sql.DB backed by the fake driver.
+func (db *fakeDB) open() *sql.DB {
+	return sql.OpenDB(db)
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return fakeConn{db}, nil
+}
+
+func (db *fakeDB) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.db.queries = append(c.db.queries, "BEGIN")
+	return fakeTx{c.db}, nil
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.queries = append(c.db.queries, query)
+	return &fakeRows{columns: c.db.columns, rows: c.db.rows}, nil
+}
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.queries = append(c.db.queries, query)
+	return driver.RowsAffected(len(c.db.rows)), nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (tx fakeTx) Commit() error {
+	tx.db.queries = append(tx.db.queries, "COMMIT")
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.db.queries = append(tx.db.queries, "ROLLBACK")
+	return nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,92 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Ident quotes an identifier with the Dialect selected by SetDialect.
+Several names are quoted one by one and joined with dots:
+	sqlbuilder.Ident("schema", "table")
+produces
+	"schema"."table"
+for PostgreSQL, `schema`.`table` for MySQL and [schema].[table] for SQL Server.
+Quote characters inside names are escaped, so it is safe to quote user input.
+Statements built with WithDialect or UsingPostgresql may use another Dialect,
+quote identifiers for them with IdentFor.
+*/
+func Ident(names ...string) string {
+	return quoteIdents(defaultDialect(), names)
+}
+
+/*
+IdentFor quotes an identifier with a given Dialect:
+	q := sqlbuilder.WithDialect(sqlbuilder.MySQL).
+		From(sqlbuilder.IdentFor(sqlbuilder.MySQL, table))
+Use it instead of Ident when a statement Dialect differs
+from the one selected by SetDialect.
+*/
+func IdentFor(d Dialect, names ...string) string {
+	return quoteIdents(d, names)
+}
+
+// quoteIdents quotes names with a Dialect and joins them with dots.
+func quoteIdents(d Dialect, names []string) string {
+	quoted := make([]string, len(names))
+	for n, name := range names {
+		quoted[n] = d.QuoteIdent(name)
+	}
+	return strings.Join(quoted, ".")
+}
+
+// orderByExprs parses a comma separated list of sort columns like
+// "-created_at,name" and maps columns to ORDER BY expressions
+// via an allowlist.
+func orderByExprs(d Dialect, input string, allowed map[string]string) ([]string, error) {
+	var exprs []string
+	for _, item := range strings.Split(input, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		dir := " ASC"
+		switch item[0] {
+		case '-':
+			dir = " DESC"
+			item = item[1:]
+		case '+':
+			item = item[1:]
+		}
+		expr, ok := allowed[item]
+		if !ok {
+			return nil, fmt.Errorf("sqlbuilder: unknown sort column %q", item)
+		}
+		if expr == "" {
+			expr = item
+		}
+		// A direction goes before NULLS FIRST or NULLS LAST
+		nulls := ""
+		upper := strings.ToUpper(expr)
+		for _, suffix := range []string{" NULLS FIRST", " NULLS LAST"} {
+			if strings.HasSuffix(upper, suffix) {
+				nulls = suffix
+				expr = strings.TrimSpace(expr[:len(expr)-len(suffix)])
+			}
+		}
+		switch {
+		case nulls == "":
+			exprs = append(exprs, expr+dir)
+		case d.Features()&FeatureNullsOrder == 0:
+			// Emulate NULLS FIRST and NULLS LAST
+			first, last := "0", "1"
+			if nulls == " NULLS LAST" {
+				first, last = last, first
+			}
+			exprs = append(exprs, "CASE WHEN "+expr+" IS NULL THEN "+first+" ELSE "+last+" END", expr+dir)
+		default:
+			exprs = append(exprs, expr+dir+nulls)
+		}
+	}
+	return exprs, nil
+}
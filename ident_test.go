@@ -0,0 +1,49 @@
+package sqlbuilder_test
+
+import (
+	"sqlbuilder"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdent(t *testing.T) {
+	assert.Equal(t, `"schema"."table"`, sqlbuilder.Ident("schema", "table"))
+	assert.Equal(t, `"a""b"`, sqlbuilder.Ident(`a"b`))
+
+	sqlbuilder.SetDialect(sqlbuilder.MySQL)
+	defer sqlbuilder.SetDialect(sqlbuilder.DefaultDialect)
+	assert.Equal(t, "`schema`.`table`", sqlbuilder.Ident("schema", "table"))
+}
+
+func TestIdentFor(t *testing.T) {
+	assert.Equal(t, "[dbo].[a]]b]", sqlbuilder.IdentFor(sqlbuilder.SQLServer, "dbo", "a]b"))
+
+	q := sqlbuilder.WithDialect(sqlbuilder.MySQL).
+		From(sqlbuilder.IdentFor(sqlbuilder.MySQL, "order")).
+		Select("id")
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM `order`", q.String())
+}
+
+func TestOrderBySafe(t *testing.T) {
+	allowed := map[string]string{
+		"created_at": "u.created_at NULLS LAST",
+		"name":       "u.name",
+		"id":         "",
+	}
+
+	stmt := sqlbuilder.From("users u").Select("u.id").OrderBySafe("-created_at, name,+id", allowed)
+	defer stmt.Close()
+	assert.Equal(t, "SELECT u.id FROM users u ORDER BY u.created_at DESC NULLS LAST, u.name ASC, id ASC", stmt.String())
+	assert.NoError(t, stmt.Err())
+
+	stmt = sqlbuilder.WithDialect(sqlbuilder.MySQL).From("users u").Select("u.id").OrderBySafe("created_at", allowed)
+	defer stmt.Close()
+	assert.Equal(t, "SELECT u.id FROM users u ORDER BY CASE WHEN u.created_at IS NULL THEN 1 ELSE 0 END, u.created_at ASC", stmt.String())
+
+	stmt = sqlbuilder.From("users u").Select("u.id").OrderBySafe("name,password; DROP TABLE users", allowed)
+	defer stmt.Close()
+	assert.Equal(t, "SELECT u.id FROM users u", stmt.String())
+	assert.EqualError(t, stmt.Err(), `sqlbuilder: unknown sort column "password; DROP TABLE users"`)
+}
@@ -0,0 +1,122 @@
+package sqlbuilder
+
+import (
+	"database/sql/driver"
+	"strings"
+	"sync/atomic"
+)
+
+// redacted is rendered in place of secret values
+const redacted = "'***'"
+
+// secret wraps a sensitive argument value.
+// It is a pointer type to be safely used as a map key.
+type secret struct {
+	value interface{}
+}
+
+// Value makes a secret usable as an argument even if it has not been unwrapped.
+func (s *secret) Value() (driver.Value, error) {
+	return driver.DefaultParameterConverter.ConvertValue(s.value)
+}
+
+/*
+Secret marks an argument value as sensitive:
+	stmt.Set("password_hash", sqlbuilder.Secret(hash))
+DebugString and executors implementing StatementLogger render
+such values as '***'. Args method returns the value itself,
+so it is passed to a database driver unchanged.
+*/
+func Secret(value interface{}) interface{} {
+	if s, ok := value.(*secret); ok {
+		return s
+	}
+	return &secret{value}
+}
+
+// secretColumns holds a set of column names selected by SetSecretColumns.
+var secretColumns atomic.Value
+
+/*
+SetSecretColumns makes values passed to Set, SetExpr, SetStruct
+and Values methods for the given columns be treated as Secret ones:
+	sqlbuilder.SetSecretColumns("password_hash", "ssn")
+Columns are matched case insensitively, regardless of a table alias and quotes.
+Each call replaces the list set by the previous one.
+*/
+func SetSecretColumns(columns ...string) {
+	set := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		set[strings.ToLower(column)] = true
+	}
+	secretColumns.Store(set)
+}
+
+// hasSecretColumns reports if any columns are listed by SetSecretColumns.
+func hasSecretColumns() bool {
+	set, _ := secretColumns.Load().(map[string]bool)
+	return len(set) > 0
+}
+
+// isSecretColumn reports if a column is listed by SetSecretColumns.
+func isSecretColumn(column string) bool {
+	set, _ := secretColumns.Load().(map[string]bool)
+	if len(set) == 0 {
+		return false
+	}
+	if k := strings.LastIndexByte(column, '.'); k >= 0 {
+		column = column[k+1:]
+	}
+	column = strings.Trim(column, "\"`[]")
+	return set[strings.ToLower(column)]
+}
+
+// secretValues wraps values with Secret.
+// The passed slice is left intact.
+func secretValues(values []interface{}) []interface{} {
+	wrapped := make([]interface{}, len(values))
+	for n, value := range values {
+		wrapped[n] = Secret(value)
+	}
+	return wrapped
+}
+
+// secretColumnValues wraps values of secret columns of a row with Secret.
+// The passed slice is copied if any of its values is to be wrapped.
+func secretColumnValues(columns []string, values []interface{}) []interface{} {
+	copied := false
+	for n, column := range columns {
+		if n == len(values) {
+			break
+		}
+		if isSecretColumn(column) {
+			if !copied {
+				values = append([]interface{}(nil), values...)
+				copied = true
+			}
+			values[n] = Secret(values[n])
+		}
+	}
+	return values
+}
+
+// unwrapSecrets replaces secret arguments with their values
+// and remembers their positions to redact them on logging.
+func (stmt *statement) unwrapSecrets() {
+	for n, arg := range stmt.outArgs {
+		if s, ok := arg.(*secret); ok {
+			stmt.outArgs[n] = s.value
+			stmt.secretArgs = append(stmt.secretArgs, n)
+		}
+	}
+}
+
+// isSecretArg reports if n-th argument of a built statement is a secret one.
+func (stmt *statement) isSecretArg(n int) bool {
+	for _, k := range stmt.secretArgs {
+		if k == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package sqlbuilder_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"sqlbuilder"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecret(t *testing.T) {
+	sqlbuilder.SetSecretColumns("password_hash")
+	defer sqlbuilder.SetSecretColumns()
+
+	stmt := sqlbuilder.WithDialect(sqlbuilder.PostgreSQL).
+		Update("users u").
+		Set("u.password_hash", "$2a$10$hash").
+		Set("pin", sqlbuilder.Secret(1234)).
+		Set("name", "Jane").
+		Where("id = ?", 42)
+	defer stmt.Close()
+	assert.Equal(t, "UPDATE users u SET u.password_hash=$1, pin=$2, name=$3 WHERE id = $4", stmt.String())
+	assert.Equal(t, []interface{}{"$2a$10$hash", 1234, "Jane", 42}, stmt.Args())
+	assert.Equal(t, "UPDATE users u SET u.password_hash='***', pin='***', name='Jane' WHERE id = 42", stmt.DebugString())
+
+	clone := stmt.Clone()
+	defer clone.Close()
+	assert.Equal(t, "UPDATE users u SET u.password_hash='***', pin='***', name='Jane' WHERE id = 42", clone.DebugString())
+
+	stmt = sqlbuilder.InsertInto("users").
+		SetExpr("password_hash", "crypt(?, gen_salt('bf'))", "hunter2").
+		Set("name", "Jane")
+	defer stmt.Close()
+	assert.Equal(t, []interface{}{"hunter2", "Jane"}, stmt.Args())
+	assert.Equal(t, "INSERT INTO users (password_hash, name) VALUES (crypt('***', gen_salt('bf')), 'Jane')", stmt.DebugString())
+
+	row := []interface{}{"Jane", "hunter2"}
+	stmt = sqlbuilder.InsertInto("users").
+		Columns("name", `"password_hash"`).
+		Values(row...).
+		Values("John", "secret")
+	defer stmt.Close()
+	assert.Equal(t, []interface{}{"Jane", "hunter2", "John", "secret"}, stmt.Args())
+	assert.Equal(t, `INSERT INTO users (name, "password_hash") VALUES ('Jane', '***'), ('John', '***')`, stmt.DebugString())
+	assert.Equal(t, []interface{}{"Jane", "hunter2"}, row)
+
+	stmt = sqlbuilder.InsertInto("archive").
+		Columns("token").
+		FromSelect(sqlbuilder.From("tokens").Select("token").Where("token = ?", sqlbuilder.Secret("abc")))
+	defer stmt.Close()
+	assert.Equal(t, []interface{}{"abc"}, stmt.Args())
+	assert.Equal(t, "INSERT INTO archive (token) SELECT token FROM tokens WHERE token = '***'", stmt.DebugString())
+}
+
+func TestSQLExecutorLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFlags(log.LstdFlags)
+
+	fake := &fakeDB{}
+	db := sqlbuilder.CreateSQLExecutor("main", fake.open(), true)
+	defer db.Close()
+
+	_, err := sqlbuilder.InsertInto("users").
+		Set("email", "jane@example.com").
+		Set("password_hash", sqlbuilder.Secret("hash")).
+		ExecAndClose(context.Background(), db)
+	assert.NoError(t, err)
+	assert.Equal(t, "main: INSERT INTO users (email, password_hash) VALUES ('jane@example.com', '***')\n", buf.String())
+	assert.Equal(t, []string{"INSERT INTO users (email, password_hash) VALUES (?, ?)"}, fake.queries)
+}
@@ -3,6 +3,7 @@ package sqlbuilder
 import (
 	"context"
 	"database/sql"
+	"log"
 )
 
 // SQLExecutor is an implementation for Executor interface using "database/sql"
@@ -49,6 +50,19 @@ func (ss *SQLExecutor) QueryRowContext(ctx context.Context, query string, args .
 	return ss.db.QueryRowContext(ctx, query, args...)
 }
 
+// BeginTx starts a transaction, so SQLExecutor can be used with BulkInserter.InTx.
+func (ss *SQLExecutor) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return ss.db.BeginTx(ctx, opts)
+}
+
+// LogStatement logs a statement with its arguments if logging is enabled.
+// Secret values are redacted.
+func (ss *SQLExecutor) LogStatement(ctx context.Context, stmt Statement) {
+	if ss.enableLog {
+		log.Printf("%s: %s", ss.name, stmt.DebugString())
+	}
+}
+
 // CreateSQLExecutor create an instance of SQLExecutor
 func CreateSQLExecutor(name string, db *sql.DB, enableLog bool) *SQLExecutor {
 	return &SQLExecutor{db: db, name: name, enableLog: enableLog}
@@ -3,6 +3,9 @@ package sqlbuilder
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/valyala/bytebufferpool"
@@ -46,6 +49,69 @@ type Statement interface {
 	*/
 	Args() []interface{}
 
+	/*
+		Err returns errors of statement construction, like a SetExpr call
+		made before InsertInto or Update, and of statement build.
+		Query, QueryRow and Exec methods return these errors without
+		passing the statement to an Executor.
+	*/
+	Err() error
+
+	/*
+		Validate checks if the number of ? placeholders of every statement clause
+		matches the number of arguments passed along with it:
+			err := sqlbuilder.From("users").
+				Select("id").
+				Where("a = ? AND b = ?", x).
+				Validate()
+			// sqlbuilder: WHERE clause "WHERE a = ? AND b = ?" has 2 placeholders, but 1 arguments are given
+		Escaped \? placeholders and question marks inside quoted strings,
+		quoted identifiers and comments are not counted.
+		All the mismatches found are reported as *PlaceholderError values.
+		Query, QueryRow and Exec methods call Validate if debug mode
+		is turned on by SetDebug.
+	*/
+	Validate() error
+
+	/*
+		DebugString returns the statement SQL with arguments inlined as literals
+		of the statement Dialect:
+			log.Printf("query failed: %v: %s", err, stmt.DebugString())
+		produces
+			query failed: ...: SELECT id FROM users WHERE email = 'jane@example.com' AND created_at > '2020-01-02 03:04:05+00:00'
+		Strings are quoted and escaped, byte slices are hex encoded,
+		driver.Valuer values are converted with their Value method
+		and nil values are rendered as NULL.
+		Secret values are rendered as '***'.
+		DebugString output is meant for logs and for running queries by hand only.
+		Never execute it: values are not escaped the way a database driver does,
+		so it is not safe against SQL injection.
+	*/
+	DebugString() string
+
+	/*
+		Bind sets values of named parameters.
+		Named parameters are referred in expressions as :name or @name
+		and are replaced with dialect specific placeholders on statement build:
+			stmt := sqlbuilder.UsingPostgresql().From("orders").
+				Select("id").
+				Where("created_at BETWEEN :from AND :to").
+				Where("updated_at < :to").
+				Bind(map[string]interface{}{"from": from, "to": to})
+		produces
+			SELECT id FROM orders WHERE created_at BETWEEN $1 AND $2 AND updated_at < $2
+		params is a map[string]interface{} or a struct. Struct fields
+		are bound by names set in db tags:
+			type period struct {
+				From time.Time `db:"from"`
+				To   time.Time `db:"to"`
+			}
+			stmt.Bind(period{From: from, To: to})
+		Struct field values are copied when Bind is called.
+		Names with no value bound are left as is.
+	*/
+	Bind(params interface{}) Statement
+
 	/*
 		Dest returns a list of value pointers passed via To method calls.
 		The order matches the constructed SQL statement.
@@ -99,6 +165,36 @@ type Statement interface {
 	*/
 	Select(expr string, args ...interface{}) Statement
 
+	/*
+		SelectStruct adds columns mapped to fields of a struct via db tags
+		to the SELECT clause and binds the fields as scan destinations.
+		dest must be a pointer to a struct:
+			type User struct {
+				ID    int64  `db:"id"`
+				Email string `db:"email"`
+				Notes string `db:"-"`
+			}
+			var user User
+			err := sqlbuilder.From("users").
+				SelectStruct(&user).
+				Where("id = ?", 42).
+				QueryRowAndClose(ctx, db)
+		produces
+			SELECT id, email FROM users WHERE id = ?
+		Fields of embedded structs are mapped as if they belonged to the outer struct.
+	*/
+	SelectStruct(dest interface{}) Statement
+
+	/*
+		SelectStructAs is a SelectStruct version that prefixes columns
+		with a table alias to select a struct from joined tables:
+			stmt := sqlbuilder.From("users u").
+				SelectStructAs("u", &user).
+				Join("orgs o", "o.id = u.org_id").
+				SelectStructAs("o", &org)
+	*/
+	SelectStructAs(alias string, dest interface{}) Statement
+
 	/*
 		To sets a scan target for columns to be selected.
 		Accepts value pointers to be passed to sql.Rows.Scan by
@@ -128,6 +224,22 @@ type Statement interface {
 	*/
 	Update(tableName string) Statement
 
+	/*
+		UpdateFrom adds tables to be joined to an UPDATE statement.
+		Join conditions go to the WHERE clause:
+			stmt := sqlbuilder.Update("orders o").
+				UpdateFrom("customers c").
+				SetExpr("discount", "c.discount").
+				Where("c.id = o.customer_id")
+		produces
+			UPDATE orders o SET discount=c.discount FROM customers c WHERE c.id = o.customer_id
+		With MySQL dialect tables are listed next to the updated one:
+			UPDATE orders o, customers c SET discount=c.discount WHERE c.id = o.customer_id
+		With SQL Server dialect the updated table is referred by its alias:
+			UPDATE o SET discount=c.discount FROM orders o, customers c WHERE c.id = o.customer_id
+	*/
+	UpdateFrom(expr string, args ...interface{}) Statement
+
 	/*
 		InsertInto adds INSERT INTO clause to a statement.
 			stmt.InsertInto("table")
@@ -136,12 +248,95 @@ type Statement interface {
 	*/
 	InsertInto(tableName string) Statement
 
+	/*
+		InsertOrReplace adds INSERT OR REPLACE INTO clause to a statement.
+		Use it with SQLite to replace rows violating a uniqueness constraint.
+			stmt.InsertOrReplace("table")
+	*/
+	InsertOrReplace(tableName string) Statement
+
+	/*
+		InsertOrIgnore adds INSERT OR IGNORE INTO clause to a statement.
+		Use it with SQLite to skip rows violating a constraint.
+			stmt.InsertOrIgnore("table")
+	*/
+	InsertOrIgnore(tableName string) Statement
+
 	/*
 		DeleteFrom adds DELETE clause to a statement.
 			stmt.DeleteFrom("table").Where("id = ?", id)
 	*/
 	DeleteFrom(tableName string) Statement
 
+	/*
+		DeleteUsing adds tables to be joined to a DELETE statement.
+		Join conditions go to the WHERE clause:
+			stmt := sqlbuilder.DeleteFrom("orders o").
+				DeleteUsing("customers c").
+				Where("c.id = o.customer_id AND c.is_deleted")
+		produces
+			DELETE FROM orders o USING customers c WHERE c.id = o.customer_id AND c.is_deleted
+		With MySQL and SQL Server dialects the statement is rendered as
+			DELETE o FROM orders o, customers c WHERE c.id = o.customer_id AND c.is_deleted
+	*/
+	DeleteUsing(expr string, args ...interface{}) Statement
+
+	/*
+		MergeInto starts a MERGE statement supported by PostgreSQL 15+ and SQL Server.
+		Add a source with Using and WHEN branches in the order they are to be checked:
+			stmt := sqlbuilder.UsingPostgresql().
+				MergeInto("stock t").
+				Using("deliveries", "d", "d.item_id = t.item_id").
+				WhenMatched().ThenUpdate().
+				SetExpr("qty", "t.qty + d.qty").
+				WhenNotMatched().ThenInsert().
+				SetExpr("item_id", "d.item_id").
+				SetExpr("qty", "d.qty")
+		produces
+			MERGE INTO stock t USING deliveries d ON d.item_id = t.item_id WHEN MATCHED THEN UPDATE SET qty=t.qty + d.qty WHEN NOT MATCHED THEN INSERT (item_id, qty) VALUES (d.item_id, d.qty)
+		Set and SetExpr calls populate the last ThenUpdate or ThenInsert branch.
+		Up to 10 WHEN branches are supported.
+	*/
+	MergeInto(tableName string) Statement
+
+	/*
+		Using adds a source to a MERGE statement.
+		source is either a table name or a Statement to be used as a subquery.
+		alias can be empty for a table source.
+	*/
+	Using(source interface{}, alias, on string, args ...interface{}) Statement
+
+	/*
+		WhenMatched adds a WHEN MATCHED branch to a MERGE statement.
+		Conditions, if any, are joined with AND.
+		Follow it with ThenUpdate or ThenDelete.
+	*/
+	WhenMatched(conds ...Cond) Statement
+
+	/*
+		WhenNotMatched adds a WHEN NOT MATCHED branch to a MERGE statement.
+		Conditions, if any, are joined with AND.
+		Follow it with ThenInsert.
+	*/
+	WhenNotMatched(conds ...Cond) Statement
+
+	/*
+		ThenUpdate adds an UPDATE action to the last WHEN branch of a MERGE statement.
+		Set and SetExpr calls that follow it populate the SET list.
+	*/
+	ThenUpdate() Statement
+
+	/*
+		ThenDelete adds a DELETE action to the last WHEN branch of a MERGE statement.
+	*/
+	ThenDelete() Statement
+
+	/*
+		ThenInsert adds an INSERT action to the last WHEN branch of a MERGE statement.
+		Set and SetExpr calls that follow it populate the lists of columns and values.
+	*/
+	ThenInsert() Statement
+
 	/*
 		Set method:
 		- Adds a column to the list of columns and a value to VALUES clause of INSERT statement,
@@ -152,6 +347,7 @@ type Statement interface {
 			stmt := sqlbuilder.InsertInto("table").Set("field", 42)
 		produces
 			INSERT INTO table (field) VALUES (42)
+		Values of columns listed by SetSecretColumns are treated as Secret ones.
 	*/
 	Set(field string, value interface{}) Statement
 
@@ -159,9 +355,123 @@ type Statement interface {
 		SetExpr is an extended version of a Set method.
 			stmt.SetExpr("field", "field + 1")
 			stmt.SetExpr("field", "? + ?", 31, 11)
+		Arguments of columns listed by SetSecretColumns are treated as Secret ones.
 	*/
 	SetExpr(field, expr string, args ...interface{}) Statement
 
+	/*
+		SetStruct calls Set for every field of a struct mapped to a column via db tag.
+		It works for both INSERT and UPDATE statements:
+			type User struct {
+				ID        int64     `db:"id,pk"`
+				Name      string    `db:"name"`
+				Email     string    `db:"email,omitempty"`
+				CreatedAt time.Time `db:"created_at,readonly"`
+			}
+			stmt := sqlbuilder.Update("users").
+				SetStruct(user, sqlbuilder.Only("name", "email")).
+				Where("id = ?", user.ID)
+		Tag options:
+			omitempty - the field is skipped if it has zero value,
+			readonly  - the field is never set,
+			pk        - the field is skipped by UPDATE statements
+			            and by INSERT statements if it has zero value.
+		Use Only option to limit the list of columns and SkipZero
+		option to skip all the fields having zero values.
+	*/
+	SetStruct(value interface{}, opts ...SetOption) Statement
+
+	/*
+		Columns adds columns to the list of columns of an INSERT statement.
+		Use it together with Values to insert several rows at once.
+	*/
+	Columns(fields ...string) Statement
+
+	/*
+		Values adds a row of values to an INSERT statement.
+		Every call adds a new row to the VALUES clause:
+			stmt := sqlbuilder.InsertInto("table").
+				Columns("a", "b").
+				Values(1, 2).
+				Values(3, 4)
+		produces
+			INSERT INTO table (a, b) VALUES (?, ?), (?, ?)
+		Values of columns listed by SetSecretColumns are treated as Secret ones.
+	*/
+	Values(values ...interface{}) Statement
+
+	/*
+		FromSelect makes an INSERT statement insert rows returned by a query
+		instead of a VALUES clause.
+		Columns, if any, must be added before FromSelect is called:
+			stmt := sqlbuilder.InsertInto("archive").
+				Columns("id", "payload").
+				FromSelect(sqlbuilder.From("events").
+					Select("id, payload").
+					Where("created_at < ?", since))
+		produces
+			INSERT INTO archive (id, payload) SELECT id, payload FROM events WHERE created_at < ?
+		The query is closed after its SQL and arguments are added to the statement.
+	*/
+	FromSelect(query Statement) Statement
+
+	/*
+		OnDuplicateKeyUpdate adds an ON DUPLICATE KEY UPDATE clause to
+		a MySQL INSERT statement.
+		Set and SetExpr calls made after OnDuplicateKeyUpdate add items
+		to the update list instead of the list of inserted columns:
+			stmt := sqlbuilder.WithDialect(sqlbuilder.MySQL).
+				InsertInto("counters").
+				Set("name", name).
+				Set("hits", 1).
+				OnDuplicateKeyUpdate().
+				SetExpr("hits", "hits + ?", 1)
+		produces
+			INSERT INTO counters (name, hits) VALUES (?, ?) ON DUPLICATE KEY UPDATE hits=hits + ?
+	*/
+	OnDuplicateKeyUpdate() Statement
+
+	/*
+		OnConflict adds an ON CONFLICT clause to a PostgreSQL or SQLite INSERT statement.
+		Columns of a unique index are passed as a conflict target.
+		Follow it with DoNothing or DoUpdateSet:
+			stmt := sqlbuilder.UsingPostgresql().
+				InsertInto("users").
+				Set("email", email).
+				Set("name", name).
+				OnConflict("email").
+				DoUpdateSet("name").
+				DoUpdateWhere("users.name <> EXCLUDED.name")
+		produces
+			INSERT INTO users (email, name) VALUES ($1, $2) ON CONFLICT (email) DO UPDATE SET name=EXCLUDED.name WHERE users.name <> EXCLUDED.name
+	*/
+	OnConflict(target ...string) Statement
+
+	/*
+		DoNothing adds a DO NOTHING action to an ON CONFLICT clause.
+	*/
+	DoNothing() Statement
+
+	/*
+		DoUpdateSet adds a DO UPDATE SET action to an ON CONFLICT clause.
+		Columns are set to values proposed for insertion.
+		Set and SetExpr calls made after DoUpdateSet add items
+		to the update list too.
+	*/
+	DoUpdateSet(columns ...string) Statement
+
+	/*
+		DoUpdateSetExpr adds an item to DO UPDATE SET action of an ON CONFLICT clause.
+			stmt.DoUpdateSetExpr("hits", "counters.hits + ?", 1)
+	*/
+	DoUpdateSetExpr(column, expr string, args ...interface{}) Statement
+
+	/*
+		DoUpdateWhere adds a WHERE condition to DO UPDATE action of an ON CONFLICT clause.
+		Several conditions are joined with AND.
+	*/
+	DoUpdateWhere(expr string, args ...interface{}) Statement
+
 	/*
 		From starts a SELECT statement.
 			var cnt int64
@@ -181,24 +491,63 @@ type Statement interface {
 				Select("id, name").
 				Where("email = ?", email).
 				Where("is_active = 1")
+		Slice arguments are expanded to lists of placeholders:
+			stmt.Where("status IN (?) AND owner_id NOT IN (?)", statuses, owners)
+		An empty slice turns IN (?) into a false condition (1=0)
+		and NOT IN (?) into a true one (1=1).
+		The same applies to arguments of any other method.
 	*/
 	Where(expr string, args ...interface{}) Statement
 
+	/*
+		WhereCond adds a filter built from a condition tree:
+			sqlbuilder.From("users").
+				Select("id, name").
+				WhereCond(sqlbuilder.Or(
+					sqlbuilder.Eq("email", email),
+					sqlbuilder.IsNull("email"),
+				))
+	*/
+	WhereCond(cond Cond) Statement
+
 	/*
 		In adds IN expression to the current filter.
 		In method must be called after a Where method call.
+		With PostgreSQL dialect values are bound as a single array
+		if their number reaches a threshold set by SetArrayThreshold.
 	*/
 	In(args ...interface{}) Statement
 
 	// OrderBy adds the ORDER BY clause to SELECT statement
 	OrderBy(expr ...string) Statement
 
+	/*
+		OrderBySafe adds an ORDER BY clause built from user input like
+		a sort parameter of a list endpoint. input is a comma separated list
+		of column names, each optionally prefixed with - for descending order.
+		Columns are mapped to SQL expressions via an allowlist:
+			stmt.OrderBySafe(r.URL.Query().Get("sort"), map[string]string{
+				"created_at": "u.created_at NULLS LAST",
+				"name":       "u.name",
+			})
+		With "-created_at,name" input it produces
+			ORDER BY u.created_at DESC NULLS LAST, u.name ASC
+		An empty expression maps a column to its name.
+		NULLS FIRST and NULLS LAST are emulated for MySQL and SQL Server.
+		A column missing in the allowlist is reported as an error by Err method
+		and no ORDER BY clause is added.
+	*/
+	OrderBySafe(input string, allowed map[string]string) Statement
+
 	// GroupBy adds the GROUP BY clause to SELECT statement
 	GroupBy(expr string) Statement
 
 	// Having adds the HAVING clause to SELECT statement
 	Having(expr string, args ...interface{}) Statement
 
+	// HavingCond adds the HAVING clause built from a condition tree to SELECT statement
+	HavingCond(cond Cond) Statement
+
 	// Limit adds a limit on number of returned rows
 	Limit(limit interface{}) Statement
 
@@ -220,7 +569,16 @@ type Statement interface {
 	// FullJoin adds a FULL OUTER JOIN clause to SELECT statement
 	FullJoin(table, on string) Statement
 
-	// Returning adds a RETURNING clause to a statement
+	/*
+		Returning adds a RETURNING clause to a statement.
+		With Oracle dialect the clause is rendered as RETURNING ... INTO
+		and values are returned via out binds to pointers set by To method:
+			err := sqlbuilder.WithDialect(sqlbuilder.Oracle).
+				InsertInto("users").
+				Set("name", name).
+				Returning("id").To(&id).
+				QueryRowAndClose(ctx, db)
+	*/
 	Returning(expr string) Statement
 
 	/*
@@ -274,6 +632,69 @@ type Statement interface {
 	// executes rows.Scan right before calling a handler function.
 	QueryAndClose(ctx context.Context, db Executor, handler func(rows *sql.Rows)) error
 
+	/*
+		QueryFunc executes the statement and calls a handler function for every row
+		of a returned dataset. If scan targets were set via To method calls,
+		rows.Scan is executed right before a handler call.
+		Iteration stops as soon as the handler returns an error,
+		which is then returned by QueryFunc:
+			err := stmt.QueryFunc(ctx, db, func(rows *sql.Rows) error {
+				if len(items) == limit {
+					return errLimitReached
+				}
+				items = append(items, item)
+				return nil
+			})
+	*/
+	QueryFunc(ctx context.Context, db Executor, handler func(rows *sql.Rows) error) error
+
+	/*
+		QueryFuncAndClose executes the statement via QueryFunc and releases
+		all the resources that can be reused to a pool.
+		Do not call any Stmt methods after this call.
+	*/
+	QueryFuncAndClose(ctx context.Context, db Executor, handler func(rows *sql.Rows) error) error
+
+	/*
+		Iter executes the statement and returns a Cursor to iterate over returned rows:
+			cur, err := stmt.Iter(ctx, db)
+			if err != nil {
+				return err
+			}
+			defer cur.Close()
+			for cur.Next() {
+				if err := cur.Scan(); err != nil {
+					return err
+				}
+			}
+			return cur.Err()
+		The statement is to be kept open until the cursor is closed
+		as Cursor.Scan uses variables bound via To method calls.
+	*/
+	Iter(ctx context.Context, db Executor) (*Cursor, error)
+
+	/*
+		QueryAll executes the statement and collects rows into a slice of structs.
+		dest is a pointer to a slice of structs or struct pointers:
+			var users []User
+			err := sqlbuilder.From("users").
+				SelectStruct(&User{}).
+				Where("is_active").
+				QueryAll(ctx, db, &users)
+		Columns are mapped to struct fields via db tags regardless of their order.
+		A column not mapped to a field or a mapped field missing in the result set
+		is reported as an error.
+		Scan targets set via To method calls are not used.
+	*/
+	QueryAll(ctx context.Context, db Executor, dest interface{}) error
+
+	/*
+		QueryAllAndClose executes the statement, collects rows into a slice of structs
+		and releases all the resources that can be reused to a pool.
+		Do not call any Stmt methods after this call.
+	*/
+	QueryAllAndClose(ctx context.Context, db Executor, dest interface{}) error
+
 	// QueryRow executes the statement via Executor methods
 	// and scans values to variables bound via To method calls.
 	QueryRow(ctx context.Context, db Executor) error
@@ -304,6 +725,29 @@ type statement struct {
 	sql      *bytebufferpool.ByteBuffer
 	args     []interface{}
 	dest     []interface{}
+	// outArgs holds args in the order of placeholders in a built statement
+	outArgs []interface{}
+	// nested is set for statements embedded into other ones,
+	// so placeholders are left to be numbered by the outer statement.
+	nested bool
+	// numbered is set while building a statement with numbered placeholders
+	numbered bool
+	// exprStart is a position in sql buffer an expression being built starts at
+	exprStart int
+	// argIndex maps arguments to placeholder numbers for ReuseEqual dialects
+	argIndex map[interface{}]int
+	// params holds named parameter values set by Bind method
+	params map[string]interface{}
+	// paramIndex maps named parameters to placeholder numbers
+	paramIndex map[string]int
+	// returningDest is an index of the first dest bound to RETURNING clause
+	returningDest int
+	// errs holds errors of statement construction
+	errs []error
+	// buildErr is an error of the last statement build
+	buildErr error
+	// secretArgs holds indexes of secret arguments of the built statement
+	secretArgs []int
 }
 
 type statementPart struct {
@@ -365,7 +809,7 @@ Use New for special cases like this:
 	}
 */
 func New(verb string, args ...interface{}) Statement {
-	stmt := getStmt(selectedDialect)
+	stmt := getStmt(defaultDialect())
 	stmt.addPart(posSelect, verb, "", args, ", ")
 	return stmt
 }
@@ -382,7 +826,7 @@ From starts a SELECT statement.
 	}
 */
 func From(expr string, args ...interface{}) Statement {
-	stmt := getStmt(selectedDialect)
+	stmt := getStmt(defaultDialect())
 	return stmt.From(expr, args...)
 }
 
@@ -399,7 +843,7 @@ Select starts a SELECT statement.
 Note that From method can also be used to start a SELECT statement.
 */
 func Select(expr string, args ...interface{}) Statement {
-	stmt := getStmt(selectedDialect)
+	stmt := getStmt(defaultDialect())
 	return stmt.Select(expr, args...)
 }
 
@@ -444,7 +888,7 @@ Update starts an UPDATE statement.
 	}
 */
 func Update(tableName string) Statement {
-	stmt := getStmt(selectedDialect)
+	stmt := getStmt(defaultDialect())
 	return stmt.Update(tableName)
 }
 
@@ -460,47 +904,56 @@ InsertInto starts an INSERT statement.
 	}
 */
 func InsertInto(tableName string) Statement {
-	stmt := getStmt(selectedDialect)
+	stmt := getStmt(defaultDialect())
 	return stmt.InsertInto(tableName)
 }
 
+/*
+MergeInto starts a MERGE statement supported by PostgreSQL 15+ and SQL Server.
+Add a source with Using and WHEN branches in the order they are to be checked:
+	stmt := sqlbuilder.UsingPostgresql().
+		MergeInto("stock t").
+		Using("deliveries", "d", "d.item_id = t.item_id").
+		WhenMatched().ThenUpdate().
+		SetExpr("qty", "t.qty + d.qty").
+		WhenNotMatched().ThenInsert().
+		SetExpr("item_id", "d.item_id").
+		SetExpr("qty", "d.qty")
+produces
+	MERGE INTO stock t USING deliveries d ON d.item_id = t.item_id WHEN MATCHED THEN UPDATE SET qty=t.qty + d.qty WHEN NOT MATCHED THEN INSERT (item_id, qty) VALUES (d.item_id, d.qty)
+Set and SetExpr calls populate the last ThenUpdate or ThenInsert branch.
+Up to 10 WHEN branches are supported.
+*/
+func MergeInto(tableName string) Statement {
+	stmt := getStmt(defaultDialect())
+	return stmt.MergeInto(tableName)
+}
+
 /*
 DeleteFrom starts a DELETE statement.
 	err := sqlbuilder.DeleteFrom("table").Where("id = ?", id).ExecAndClose(ctx, db)
 */
 func DeleteFrom(tableName string) Statement {
-	stmt := getStmt(selectedDialect)
+	stmt := getStmt(defaultDialect())
 	return stmt.DeleteFrom(tableName)
 }
 
 // String method builds and returns an SQL statement.
 func (stmt *statement) String() string {
 	if stmt.sql == nil {
-		var argNo int = 1
-		// Build a query
-		buf := getBuffer()
-		stmt.sql = buf
-
-		pos := 0
-		for n, part := range stmt.parts {
-			// Separate clauses with spaces
-			if n > 0 && part.position > pos {
-				buf.Write(space)
-			}
-			s := stmt.buffer.B[part.bufLow:part.bufHigh]
-			if part.argLen > 0 && stmt.dialect == PostgreSQL {
-				argNo, _ = writePostgresql(argNo, s, buf)
-			} else {
-				buf.Write(s)
-			}
-			pos = part.position
-		}
+		stmt.build()
 	}
 	return bufferToString(&stmt.sql.B)
 }
 
 func (stmt *statement) SetDialect(value Dialect) {
-	stmt.dialect = value
+	if value == nil {
+		value = DefaultDialect
+	}
+	if stmt.dialect != value {
+		stmt.dialect = value
+		stmt.Invalidate()
+	}
 }
 
 func (stmt *statement) GetDialect() Dialect {
@@ -516,7 +969,80 @@ adds a clause or an expression with arguments.
 Make sure to make a copy of the returned slice if you need to preserve it.
 */
 func (stmt *statement) Args() []interface{} {
-	return stmt.args
+	// Some dialects render arguments in a different order,
+	// so the list is built along with the statement.
+	if stmt.sql == nil {
+		stmt.build()
+	}
+	return stmt.outArgs
+}
+
+/*
+Err returns errors of statement construction, like a SetExpr call
+made before InsertInto or Update, and of statement build.
+Query, QueryRow and Exec methods return these errors without
+passing the statement to an Executor.
+*/
+func (stmt *statement) Err() error {
+	if stmt.sql == nil {
+		stmt.build()
+	}
+	errs := stmt.errs
+	if stmt.buildErr != nil {
+		errs = append(errs[:len(errs):len(errs)], stmt.buildErr)
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return errorList(errs)
+}
+
+/*
+Bind sets values of named parameters.
+Named parameters are referred in expressions as :name or @name
+and are replaced with dialect specific placeholders on statement build:
+	stmt := sqlbuilder.UsingPostgresql().From("orders").
+		Select("id").
+		Where("created_at BETWEEN :from AND :to").
+		Where("updated_at < :to").
+		Bind(map[string]interface{}{"from": from, "to": to})
+produces
+	SELECT id FROM orders WHERE created_at BETWEEN $1 AND $2 AND updated_at < $2
+params is a map[string]interface{} or a struct. Struct fields
+are bound by names set in db tags:
+	type period struct {
+		From time.Time `db:"from"`
+		To   time.Time `db:"to"`
+	}
+	stmt.Bind(period{From: from, To: to})
+Struct field values are copied when Bind is called.
+Names with no value bound are left as is.
+*/
+func (stmt *statement) Bind(params interface{}) Statement {
+	if stmt.params == nil {
+		stmt.params = make(map[string]interface{})
+	}
+	if m, ok := params.(map[string]interface{}); ok {
+		for name, value := range m {
+			stmt.params[name] = value
+		}
+	} else {
+		v := reflect.Indirect(reflect.ValueOf(params))
+		if v.Kind() == reflect.Struct {
+			for _, field := range structFields(v.Type()) {
+				if fv, ok := fieldByIndex(v, field.index); ok {
+					stmt.params[field.column] = fv.Interface()
+				}
+			}
+		} else {
+			stmt.fail(fmt.Errorf("sqlbuilder: Bind expects a map or a struct, got %T", params))
+		}
+	}
+	stmt.Invalidate()
+	return stmt
 }
 
 /*
@@ -558,10 +1084,18 @@ func (stmt *statement) Clone() Statement {
 
 	newstmt.args = insertAt(newstmt.args, stmt.args, 0)
 	newstmt.dest = insertAt(newstmt.dest, stmt.dest, 0)
+	newstmt.returningDest = stmt.returningDest
+	newstmt.errs = append(newstmt.errs, stmt.errs...)
+	if len(stmt.params) > 0 {
+		newstmt.Bind(stmt.params)
+	}
 	newstmt.buffer.Write(stmt.buffer.B)
 	if stmt.sql != nil {
 		newstmt.sql = getBuffer()
 		newstmt.sql.Write(stmt.sql.B)
+		newstmt.outArgs = insertAt(newstmt.outArgs, stmt.outArgs, 0)
+		newstmt.secretArgs = append(newstmt.secretArgs, stmt.secretArgs...)
+		newstmt.buildErr = stmt.buildErr
 	}
 
 	return newstmt
@@ -597,6 +1131,54 @@ func (stmt *statement) Select(expr string, args ...interface{}) Statement {
 	return stmt
 }
 
+/*
+SelectStruct adds columns mapped to fields of a struct via db tags
+to the SELECT clause and binds the fields as scan destinations.
+dest must be a pointer to a struct:
+	type User struct {
+		ID    int64  `db:"id"`
+		Email string `db:"email"`
+		Notes string `db:"-"`
+	}
+	var user User
+	err := sqlbuilder.From("users").
+		SelectStruct(&user).
+		Where("id = ?", 42).
+		QueryRowAndClose(ctx, db)
+produces
+	SELECT id, email FROM users WHERE id = ?
+Fields of embedded structs are mapped as if they belonged to the outer struct.
+*/
+func (stmt *statement) SelectStruct(dest interface{}) Statement {
+	return stmt.SelectStructAs("", dest)
+}
+
+/*
+SelectStructAs is a SelectStruct version that prefixes columns
+with a table alias to select a struct from joined tables:
+	stmt := sqlbuilder.From("users u").
+		SelectStructAs("u", &user).
+		Join("orgs o", "o.id = u.org_id").
+		SelectStructAs("o", &org)
+*/
+func (stmt *statement) SelectStructAs(alias string, dest interface{}) Statement {
+	columns, ptrs, ok := structDest(dest)
+	if !ok {
+		stmt.fail(fmt.Errorf("sqlbuilder: SelectStruct expects a pointer to a struct, got %T", dest))
+		return stmt
+	}
+	if len(columns) == 0 {
+		return stmt
+	}
+	if alias != "" {
+		for n, column := range columns {
+			columns[n] = alias + "." + column
+		}
+	}
+	stmt.addPart(posSelect, "SELECT", strings.Join(columns, ", "), nil, ", ")
+	return stmt.To(ptrs...)
+}
+
 /*
 UpdateUser adds UPDATE clause to a statement.
 	stmt.UpdateUser("table")
@@ -608,6 +1190,25 @@ func (stmt *statement) Update(tableName string) Statement {
 	return stmt
 }
 
+/*
+UpdateFrom adds tables to be joined to an UPDATE statement.
+Join conditions go to the WHERE clause:
+	stmt := sqlbuilder.Update("orders o").
+		UpdateFrom("customers c").
+		SetExpr("discount", "c.discount").
+		Where("c.id = o.customer_id")
+produces
+	UPDATE orders o SET discount=c.discount FROM customers c WHERE c.id = o.customer_id
+With MySQL dialect tables are listed next to the updated one:
+	UPDATE orders o, customers c SET discount=c.discount WHERE c.id = o.customer_id
+With SQL Server dialect the updated table is referred by its alias:
+	UPDATE o SET discount=c.discount FROM orders o, customers c WHERE c.id = o.customer_id
+*/
+func (stmt *statement) UpdateFrom(expr string, args ...interface{}) Statement {
+	stmt.addPart(posUpdateFrom, "", expr, args, ", ")
+	return stmt
+}
+
 /*
 InsertInto adds INSERT INTO clause to a statement.
 	stmt.InsertInto("table")
@@ -615,11 +1216,27 @@ tableName argument can be a SQL fragment:
 	stmt.InsertInto("table AS t")
 */
 func (stmt *statement) InsertInto(tableName string) Statement {
-	stmt.addPart(posInsert, "INSERT INTO", tableName, nil, ", ")
-	stmt.addPart(posInsertFields-1, "(", "", nil, "")
-	stmt.addPart(posValues-1, ") VALUES (", "", nil, "")
-	stmt.addPart(posValues+1, ")", "", nil, "")
-	stmt.position = posInsertFields
+	stmt.insert("INSERT INTO", tableName)
+	return stmt
+}
+
+/*
+InsertOrReplace adds INSERT OR REPLACE INTO clause to a statement.
+Use it with SQLite to replace rows violating a uniqueness constraint.
+	stmt.InsertOrReplace("table")
+*/
+func (stmt *statement) InsertOrReplace(tableName string) Statement {
+	stmt.insert("INSERT OR REPLACE INTO", tableName)
+	return stmt
+}
+
+/*
+InsertOrIgnore adds INSERT OR IGNORE INTO clause to a statement.
+Use it with SQLite to skip rows violating a constraint.
+	stmt.InsertOrIgnore("table")
+*/
+func (stmt *statement) InsertOrIgnore(tableName string) Statement {
+	stmt.insert("INSERT OR IGNORE INTO", tableName)
 	return stmt
 }
 
@@ -632,6 +1249,131 @@ func (stmt *statement) DeleteFrom(tableName string) Statement {
 	return stmt
 }
 
+/*
+DeleteUsing adds tables to be joined to a DELETE statement.
+Join conditions go to the WHERE clause:
+	stmt := sqlbuilder.DeleteFrom("orders o").
+		DeleteUsing("customers c").
+		Where("c.id = o.customer_id AND c.is_deleted")
+produces
+	DELETE FROM orders o USING customers c WHERE c.id = o.customer_id AND c.is_deleted
+With MySQL and SQL Server dialects the statement is rendered as
+	DELETE o FROM orders o, customers c WHERE c.id = o.customer_id AND c.is_deleted
+*/
+func (stmt *statement) DeleteUsing(expr string, args ...interface{}) Statement {
+	stmt.addPart(posDeleteUsing, "", expr, args, ", ")
+	return stmt
+}
+
+/*
+MergeInto starts a MERGE statement supported by PostgreSQL 15+ and SQL Server.
+Add a source with Using and WHEN branches in the order they are to be checked:
+	stmt := sqlbuilder.UsingPostgresql().
+		MergeInto("stock t").
+		Using("deliveries", "d", "d.item_id = t.item_id").
+		WhenMatched().ThenUpdate().
+		SetExpr("qty", "t.qty + d.qty").
+		WhenNotMatched().ThenInsert().
+		SetExpr("item_id", "d.item_id").
+		SetExpr("qty", "d.qty")
+produces
+	MERGE INTO stock t USING deliveries d ON d.item_id = t.item_id WHEN MATCHED THEN UPDATE SET qty=t.qty + d.qty WHEN NOT MATCHED THEN INSERT (item_id, qty) VALUES (d.item_id, d.qty)
+Set and SetExpr calls populate the last ThenUpdate or ThenInsert branch.
+Up to 10 WHEN branches are supported.
+*/
+func (stmt *statement) MergeInto(tableName string) Statement {
+	stmt.addPart(posMerge, "MERGE INTO", tableName, nil, ", ")
+	return stmt
+}
+
+/*
+Using adds a source to a MERGE statement.
+source is either a table name or a Statement to be used as a subquery.
+alias can be empty for a table source.
+*/
+func (stmt *statement) Using(source interface{}, alias, on string, args ...interface{}) Statement {
+	var expr string
+	switch src := source.(type) {
+	case Statement:
+		stmt.nest(src)
+		expr = "(" + src.String() + ")"
+		args = append(append([]interface{}(nil), src.Args()...), args...)
+		// Close the subquery
+		src.Close()
+	default:
+		expr = fmt.Sprint(src)
+	}
+	if alias != "" {
+		expr += " " + alias
+	}
+	stmt.addPart(posMergeUsing, "USING", expr+" ON "+on, args, "")
+	return stmt
+}
+
+/*
+WhenMatched adds a WHEN MATCHED branch to a MERGE statement.
+Conditions, if any, are joined with AND.
+Follow it with ThenUpdate or ThenDelete.
+*/
+func (stmt *statement) WhenMatched(conds ...Cond) Statement {
+	stmt.mergeBranch("WHEN MATCHED", conds)
+	return stmt
+}
+
+/*
+WhenNotMatched adds a WHEN NOT MATCHED branch to a MERGE statement.
+Conditions, if any, are joined with AND.
+Follow it with ThenInsert.
+*/
+func (stmt *statement) WhenNotMatched(conds ...Cond) Statement {
+	stmt.mergeBranch("WHEN NOT MATCHED", conds)
+	return stmt
+}
+
+/*
+ThenUpdate adds an UPDATE action to the last WHEN branch of a MERGE statement.
+Set and SetExpr calls that follow it populate the SET list.
+*/
+func (stmt *statement) ThenUpdate() Statement {
+	branch := stmt.lastMergeBranch()
+	if branch == 0 {
+		stmt.fail(errors.New("sqlbuilder: ThenUpdate requires a preceding WhenMatched or WhenNotMatched call"))
+		return stmt
+	}
+	stmt.addPart(branch+1, "THEN UPDATE SET", "", nil, ", ")
+	return stmt
+}
+
+/*
+ThenDelete adds a DELETE action to the last WHEN branch of a MERGE statement.
+*/
+func (stmt *statement) ThenDelete() Statement {
+	branch := stmt.lastMergeBranch()
+	if branch == 0 {
+		stmt.fail(errors.New("sqlbuilder: ThenDelete requires a preceding WhenMatched or WhenNotMatched call"))
+		return stmt
+	}
+	stmt.addPart(branch+7, "THEN DELETE", "", nil, "")
+	return stmt
+}
+
+/*
+ThenInsert adds an INSERT action to the last WHEN branch of a MERGE statement.
+Set and SetExpr calls that follow it populate the lists of columns and values.
+*/
+func (stmt *statement) ThenInsert() Statement {
+	branch := stmt.lastMergeBranch()
+	if branch == 0 {
+		stmt.fail(errors.New("sqlbuilder: ThenInsert requires a preceding WhenMatched or WhenNotMatched call"))
+		return stmt
+	}
+	stmt.addPart(branch+1, "THEN INSERT", "", nil, "")
+	stmt.addPart(branch+2, "(", "", nil, "")
+	stmt.addPart(branch+4, ") VALUES (", "", nil, "")
+	stmt.addPart(branch+6, ")", "", nil, "")
+	return stmt
+}
+
 /*
 Set method:
 - Adds a column to the list of columns and a value to VALUES clause of INSERT statement,
@@ -642,6 +1384,7 @@ both the list of columns and values to be inserted:
 	stmt := sqlbuilder.InsertInto("table").Set("field", 42)
 produces
 	INSERT INTO table (field) VALUES (42)
+Values of columns listed by SetSecretColumns are treated as Secret ones.
 */
 func (stmt *statement) Set(field string, value interface{}) Statement {
 	return stmt.SetExpr(field, "?", value)
@@ -651,16 +1394,25 @@ func (stmt *statement) Set(field string, value interface{}) Statement {
 SetExpr is an extended version of a Set method.
 	stmt.SetExpr("field", "field + 1")
 	stmt.SetExpr("field", "? + ?", 31, 11)
+Arguments of columns listed by SetSecretColumns are treated as Secret ones.
 */
 func (stmt *statement) SetExpr(field, expr string, args ...interface{}) Statement {
-	// TODO How to handle both INSERT ... VALUES and SET in ON DUPLICATE KEY UPDATE?
+	if isSecretColumn(field) {
+		args = secretValues(args)
+	}
+	// An upsert clause follows INSERT, so it takes precedence
+	// once it has been added to a statement.
 	p := 0
 	for _, part := range stmt.parts {
-		if part.position == posInsert || part.position == posUpdate {
+		switch part.position {
+		case posInsert, posUpdate, posOnConflict, posDoUpdateSet, posDoNothing, posOnDuplicateKey:
 			p = part.position
-			break
 		}
 	}
+	// So do MERGE branches
+	if branch := stmt.lastMergeBranch(); branch > 0 {
+		p = branch
+	}
 
 	switch p {
 	case posInsert:
@@ -668,7 +1420,235 @@ func (stmt *statement) SetExpr(field, expr string, args ...interface{}) Statemen
 		stmt.addPart(posValues, "", expr, args, ", ")
 	case posUpdate:
 		stmt.addPart(posSet, "SET", field+"="+expr, args, ", ")
+	case posOnDuplicateKey:
+		stmt.addPart(posOnDuplicateKey, "ON DUPLICATE KEY UPDATE", field+"="+expr, args, ", ")
+	case posDoUpdateSet:
+		stmt.addPart(posDoUpdateSet, "DO UPDATE SET", field+"="+expr, args, ", ")
+	case posOnConflict:
+		stmt.fail(fmt.Errorf("sqlbuilder: Set(%q) after OnConflict requires a DoUpdateSet call", field))
+	case posDoNothing:
+		stmt.fail(fmt.Errorf("sqlbuilder: Set(%q) can not follow DoNothing", field))
+	default:
+		switch {
+		case p < posMergeWhen:
+			stmt.fail(fmt.Errorf("sqlbuilder: Set(%q) requires an INSERT, UPDATE or upsert clause", field))
+		case stmt.hasPart(p + 4):
+			// INSERT action of a MERGE branch
+			stmt.addPart(p+3, "", field, nil, ", ")
+			stmt.addPart(p+5, "", expr, args, ", ")
+		case stmt.hasPart(p + 1):
+			stmt.addPart(p+1, "THEN UPDATE SET", field+"="+expr, args, ", ")
+		case stmt.hasPart(p + 7):
+			stmt.fail(fmt.Errorf("sqlbuilder: Set(%q) can not follow ThenDelete", field))
+		default:
+			stmt.fail(fmt.Errorf("sqlbuilder: Set(%q) requires a ThenUpdate or ThenInsert call", field))
+		}
+	}
+	return stmt
+}
+
+/*
+SetStruct calls Set for every field of a struct mapped to a column via db tag.
+It works for both INSERT and UPDATE statements:
+	type User struct {
+		ID        int64     `db:"id,pk"`
+		Name      string    `db:"name"`
+		Email     string    `db:"email,omitempty"`
+		CreatedAt time.Time `db:"created_at,readonly"`
+	}
+	stmt := sqlbuilder.Update("users").
+		SetStruct(user, sqlbuilder.Only("name", "email")).
+		Where("id = ?", user.ID)
+Tag options:
+	omitempty - the field is skipped if it has zero value,
+	readonly  - the field is never set,
+	pk        - the field is skipped by UPDATE statements
+	            and by INSERT statements if it has zero value.
+Use Only option to limit the list of columns and SkipZero
+option to skip all the fields having zero values.
+*/
+func (stmt *statement) SetStruct(value interface{}, opts ...SetOption) Statement {
+	if reflect.Indirect(reflect.ValueOf(value)).Kind() != reflect.Struct {
+		stmt.fail(fmt.Errorf("sqlbuilder: SetStruct expects a struct, got %T", value))
+		return stmt
+	}
+	// Upsert clauses and MERGE branches update rows as well
+	isUpdate := false
+	for _, part := range stmt.parts {
+		switch part.position {
+		case posInsert:
+			isUpdate = false
+		case posUpdate, posOnConflict, posDoUpdateSet, posDoNothing, posOnDuplicateKey:
+			isUpdate = true
+		}
 	}
+	if branch := stmt.lastMergeBranch(); branch > 0 {
+		isUpdate = !stmt.hasPart(branch + 4)
+	}
+	setStruct(value, isUpdate, opts, func(column string, value interface{}) {
+		stmt.Set(column, value)
+	})
+	return stmt
+}
+
+/*
+Columns adds columns to the list of columns of an INSERT statement.
+Use it together with Values to insert several rows at once.
+*/
+func (stmt *statement) Columns(fields ...string) Statement {
+	stmt.addPart(posInsertFields, "", strings.Join(fields, ", "), nil, ", ")
+	return stmt
+}
+
+/*
+Values adds a row of values to an INSERT statement.
+Every call adds a new row to the VALUES clause:
+	stmt := sqlbuilder.InsertInto("table").
+		Columns("a", "b").
+		Values(1, 2).
+		Values(3, 4)
+produces
+	INSERT INTO table (a, b) VALUES (?, ?), (?, ?)
+Values of columns listed by SetSecretColumns are treated as Secret ones.
+*/
+func (stmt *statement) Values(values ...interface{}) Statement {
+	if !stmt.hasPart(posInsert) {
+		stmt.fail(errors.New("sqlbuilder: Values requires an INSERT clause"))
+		return stmt
+	}
+	if len(values) == 0 {
+		return stmt
+	}
+	if hasSecretColumns() {
+		values = secretColumnValues(stmt.insertColumns(), values)
+	}
+	// Rows are separated by closing and opening brackets
+	expr := "?" + strings.Repeat(", ?", len(values)-1)
+	stmt.addPart(posValues, "", expr, values, "), (")
+	return stmt
+}
+
+/*
+FromSelect makes an INSERT statement insert rows returned by a query
+instead of a VALUES clause.
+Columns, if any, must be added before FromSelect is called:
+	stmt := sqlbuilder.InsertInto("archive").
+		Columns("id", "payload").
+		FromSelect(sqlbuilder.From("events").
+			Select("id, payload").
+			Where("created_at < ?", since))
+produces
+	INSERT INTO archive (id, payload) SELECT id, payload FROM events WHERE created_at < ?
+The query is closed after its SQL and arguments are added to the statement.
+*/
+func (stmt *statement) FromSelect(query Statement) Statement {
+	if !stmt.hasPart(posInsert) {
+		stmt.fail(errors.New("sqlbuilder: FromSelect requires an INSERT clause"))
+		return stmt
+	}
+	// Drop the VALUES clause
+	stmt.removeParts(posValues - 1)
+	stmt.removeParts(posValues)
+	stmt.removeParts(posValues + 1)
+	if stmt.hasPart(posInsertFields) {
+		stmt.addPart(posValues-1, ")", "", nil, "")
+	} else {
+		stmt.removeParts(posInsertFields - 1)
+	}
+
+	stmt.nest(query)
+	index := stmt.addPart(posValues, "", "", query.Args(), "")
+	part := &stmt.parts[index]
+	stmt.buffer.WriteString(query.String())
+	part.bufHigh = stmt.buffer.Len()
+	part.hasExpr = true
+	// Close the subquery
+	query.Close()
+
+	return stmt
+}
+
+/*
+OnDuplicateKeyUpdate adds an ON DUPLICATE KEY UPDATE clause to
+a MySQL INSERT statement.
+Set and SetExpr calls made after OnDuplicateKeyUpdate add items
+to the update list instead of the list of inserted columns:
+	stmt := sqlbuilder.WithDialect(sqlbuilder.MySQL).
+		InsertInto("counters").
+		Set("name", name).
+		Set("hits", 1).
+		OnDuplicateKeyUpdate().
+		SetExpr("hits", "hits + ?", 1)
+produces
+	INSERT INTO counters (name, hits) VALUES (?, ?) ON DUPLICATE KEY UPDATE hits=hits + ?
+*/
+func (stmt *statement) OnDuplicateKeyUpdate() Statement {
+	stmt.addPart(posOnDuplicateKey, "ON DUPLICATE KEY UPDATE", "", nil, ", ")
+	return stmt
+}
+
+/*
+OnConflict adds an ON CONFLICT clause to a PostgreSQL or SQLite INSERT statement.
+Columns of a unique index are passed as a conflict target.
+Follow it with DoNothing or DoUpdateSet:
+	stmt := sqlbuilder.UsingPostgresql().
+		InsertInto("users").
+		Set("email", email).
+		Set("name", name).
+		OnConflict("email").
+		DoUpdateSet("name").
+		DoUpdateWhere("users.name <> EXCLUDED.name")
+produces
+	INSERT INTO users (email, name) VALUES ($1, $2) ON CONFLICT (email) DO UPDATE SET name=EXCLUDED.name WHERE users.name <> EXCLUDED.name
+*/
+func (stmt *statement) OnConflict(target ...string) Statement {
+	expr := ""
+	if len(target) > 0 {
+		expr = "(" + strings.Join(target, ", ") + ")"
+	}
+	stmt.addPart(posOnConflict, "ON CONFLICT", expr, nil, " ")
+	return stmt
+}
+
+/*
+DoNothing adds a DO NOTHING action to an ON CONFLICT clause.
+*/
+func (stmt *statement) DoNothing() Statement {
+	stmt.addPart(posDoNothing, "DO NOTHING", "", nil, "")
+	return stmt
+}
+
+/*
+DoUpdateSet adds a DO UPDATE SET action to an ON CONFLICT clause.
+Columns are set to values proposed for insertion.
+Set and SetExpr calls made after DoUpdateSet add items
+to the update list too.
+*/
+func (stmt *statement) DoUpdateSet(columns ...string) Statement {
+	if len(columns) == 0 {
+		stmt.addPart(posDoUpdateSet, "DO UPDATE SET", "", nil, ", ")
+	}
+	for _, column := range columns {
+		stmt.addPart(posDoUpdateSet, "DO UPDATE SET", column+"=EXCLUDED."+column, nil, ", ")
+	}
+	return stmt
+}
+
+/*
+DoUpdateSetExpr adds an item to DO UPDATE SET action of an ON CONFLICT clause.
+	stmt.DoUpdateSetExpr("hits", "counters.hits + ?", 1)
+*/
+func (stmt *statement) DoUpdateSetExpr(column, expr string, args ...interface{}) Statement {
+	stmt.addPart(posDoUpdateSet, "DO UPDATE SET", column+"="+expr, args, ", ")
+	return stmt
+}
+
+/*
+DoUpdateWhere adds a WHERE condition to DO UPDATE action of an ON CONFLICT clause.
+Several conditions are joined with AND.
+*/
+func (stmt *statement) DoUpdateWhere(expr string, args ...interface{}) Statement {
+	stmt.addPart(posDoUpdateWhere, "WHERE", expr, args, " AND ")
 	return stmt
 }
 
@@ -684,32 +1664,52 @@ Where adds a filter:
 		Select("id, name").
 		Where("email = ?", email).
 		Where("is_active = 1")
+Slice arguments are expanded to lists of placeholders:
+	stmt.Where("status IN (?) AND owner_id NOT IN (?)", statuses, owners)
+An empty slice turns IN (?) into a false condition (1=0)
+and NOT IN (?) into a true one (1=1).
+The same applies to arguments of any other method.
 */
 func (stmt *statement) Where(expr string, args ...interface{}) Statement {
 	stmt.addPart(posWhere, "WHERE", expr, args, " AND ")
 	return stmt
 }
 
+/*
+WhereCond adds a filter built from a condition tree:
+	sqlbuilder.From("users").
+		Select("id, name").
+		WhereCond(sqlbuilder.Or(
+			sqlbuilder.Eq("email", email),
+			sqlbuilder.IsNull("email"),
+		))
+*/
+func (stmt *statement) WhereCond(cond Cond) Statement {
+	expr, args := condString(cond, nil)
+	return stmt.Where(expr, args...)
+}
+
 /*
 In adds IN expression to the current filter.
 In method must be called after a Where method call.
+With PostgreSQL dialect values are bound as a single array
+if their number reaches a threshold set by SetArrayThreshold.
 */
 func (stmt *statement) In(args ...interface{}) Statement {
-	buf := bytebufferpool.Get()
-	buf.WriteString("IN (")
-	l := len(args) - 1
-	for i := range args {
-		if i < l {
-			buf.Write(placeholderComma)
-		} else {
-			buf.Write(placeholder)
-		}
+	if stmt.position != posWhere {
+		stmt.fail(errors.New("sqlbuilder: In must follow a Where call"))
+		return stmt
 	}
-	buf.WriteString(")")
-
-	stmt.addPart(posWhere, "", bufferToString(&buf.B), args, " ")
-
-	bytebufferpool.Put(buf)
+	// Bind values as a single list to be expanded on statement build
+	var list interface{}
+	if len(args) == 1 && hasList(args) {
+		list = args[0]
+	} else {
+		values := make([]interface{}, len(args))
+		copy(values, args)
+		list = values
+	}
+	stmt.addPart(posWhere, "", "IN (?)", []interface{}{list}, " ")
 	return stmt
 }
 
@@ -719,6 +1719,34 @@ func (stmt *statement) OrderBy(expr ...string) Statement {
 	return stmt
 }
 
+/*
+OrderBySafe adds an ORDER BY clause built from user input like
+a sort parameter of a list endpoint. input is a comma separated list
+of column names, each optionally prefixed with - for descending order.
+Columns are mapped to SQL expressions via an allowlist:
+	stmt.OrderBySafe(r.URL.Query().Get("sort"), map[string]string{
+		"created_at": "u.created_at NULLS LAST",
+		"name":       "u.name",
+	})
+With "-created_at,name" input it produces
+	ORDER BY u.created_at DESC NULLS LAST, u.name ASC
+An empty expression maps a column to its name.
+NULLS FIRST and NULLS LAST are emulated for MySQL and SQL Server.
+A column missing in the allowlist is reported as an error by Err method
+and no ORDER BY clause is added.
+*/
+func (stmt *statement) OrderBySafe(input string, allowed map[string]string) Statement {
+	exprs, err := orderByExprs(stmt.dialect, input, allowed)
+	if err != nil {
+		stmt.fail(err)
+		return stmt
+	}
+	if len(exprs) == 0 {
+		return stmt
+	}
+	return stmt.OrderBy(exprs...)
+}
+
 // GroupBy adds the GROUP BY clause to SELECT statement
 func (stmt *statement) GroupBy(expr string) Statement {
 	stmt.addPart(posGroupBy, "GROUP BY", expr, nil, ", ")
@@ -731,6 +1759,12 @@ func (stmt *statement) Having(expr string, args ...interface{}) Statement {
 	return stmt
 }
 
+// HavingCond adds the HAVING clause built from a condition tree to SELECT statement
+func (stmt *statement) HavingCond(cond Cond) Statement {
+	expr, args := condString(cond, nil)
+	return stmt.Having(expr, args...)
+}
+
 // Limit adds a limit on number of returned rows
 func (stmt *statement) Limit(limit interface{}) Statement {
 	stmt.addPart(posLimit, "LIMIT ?", "", []interface{}{limit}, "")
@@ -790,8 +1824,21 @@ func (stmt *statement) FullJoin(table, on string) Statement {
 	return stmt
 }
 
-// Returning adds a RETURNING clause to a statement
+/*
+Returning adds a RETURNING clause to a statement.
+With Oracle dialect the clause is rendered as RETURNING ... INTO
+and values are returned via out binds to pointers set by To method:
+	err := sqlbuilder.WithDialect(sqlbuilder.Oracle).
+		InsertInto("users").
+		Set("name", name).
+		Returning("id").To(&id).
+		QueryRowAndClose(ctx, db)
+*/
 func (stmt *statement) Returning(expr string) Statement {
+	if !stmt.hasPart(posReturning) {
+		// Remember where the list of returned value pointers starts
+		stmt.returningDest = len(stmt.dest)
+	}
 	stmt.addPart(posReturning, "RETURNING", expr, nil, ", ")
 	return stmt
 }
@@ -823,13 +1870,9 @@ func (stmt *statement) SubQuery(prefix, suffix string, query Statement) Statemen
 	if stmt.position == posWhere {
 		delimiter = " AND "
 	}
+	stmt.nest(query)
 	index := stmt.addPart(stmt.position, "", prefix, query.Args(), delimiter)
 	part := &stmt.parts[index]
-	// Make sure subquery is not dialect-specific.
-	if query.GetDialect() != DefaultDialect {
-		query.SetDialect(DefaultDialect)
-		query.Invalidate()
-	}
 	stmt.buffer.WriteString(query.String())
 	stmt.buffer.WriteString(suffix)
 	part.bufHigh = stmt.buffer.Len()
@@ -853,6 +1896,7 @@ func (stmt *statement) Union(all bool, query Statement) Statement {
 			p = last + 1
 		}
 	}
+	stmt.nest(query)
 	var index int
 	if all {
 		index = stmt.addPart(p, "UNION ALL ", "", query.Args(), "")
@@ -860,11 +1904,6 @@ func (stmt *statement) Union(all bool, query Statement) Statement {
 		index = stmt.addPart(p, "UNION ", "", query.Args(), "")
 	}
 	part := &stmt.parts[index]
-	// Make sure subquery is not dialect-specific.
-	if query.GetDialect() != DefaultDialect {
-		query.SetDialect(DefaultDialect)
-		query.Invalidate()
-	}
 	stmt.buffer.WriteString(query.String())
 	part.bufHigh = stmt.buffer.Len()
 	// Close the subquery
@@ -895,12 +1934,48 @@ func (stmt *statement) Clause(expr string, args ...interface{}) Statement {
 // If scan targets were set via To method calls, Query method
 // executes rows.Scan right before calling a handler function.
 func (stmt *statement) Query(ctx context.Context, db Executor, handler func(rows *sql.Rows)) error {
+	return stmt.QueryFunc(ctx, db, func(rows *sql.Rows) error {
+		handler(rows)
+		return nil
+	})
+}
+
+// QueryAndClose executes the statement and releases all the resources that
+// can be reused to a pool. Do not call any Stmt methods after this call.
+// For every row of a returned dataset QueryAndClose executes a handler function.
+// If scan targets were set via To method calls, QueryAndClose method
+// executes rows.Scan right before calling a handler function.
+func (stmt *statement) QueryAndClose(ctx context.Context, db Executor, handler func(rows *sql.Rows)) error {
+	err := stmt.Query(ctx, db, handler)
+	stmt.Close()
+	return err
+}
+
+/*
+QueryFunc executes the statement and calls a handler function for every row
+of a returned dataset. If scan targets were set via To method calls,
+rows.Scan is executed right before a handler call.
+Iteration stops as soon as the handler returns an error,
+which is then returned by QueryFunc:
+	err := stmt.QueryFunc(ctx, db, func(rows *sql.Rows) error {
+		if len(items) == limit {
+			return errLimitReached
+		}
+		items = append(items, item)
+		return nil
+	})
+*/
+func (stmt *statement) QueryFunc(ctx context.Context, db Executor, handler func(rows *sql.Rows) error) error {
+	if err := stmt.check(); err != nil {
+		return err
+	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	logStatement(ctx, db, stmt)
 
 	// Fetch rows
-	rows, err := db.QueryContext(ctx, stmt.String(), stmt.args...)
+	rows, err := db.QueryContext(ctx, stmt.String(), stmt.Args()...)
 	if err != nil {
 		return err
 	}
@@ -914,16 +1989,19 @@ func (stmt *statement) Query(ctx context.Context, db Executor, handler func(rows
 			}
 		}
 		// Call a callback function
-		handler(rows)
+		err = handler(rows)
+		if err != nil {
+			break
+		}
 	}
 	// Check for errors during rows "Close".
 	// This may be more important if multiple statements are executed
 	// in a single batch and rows were written as well as read.
-	if closeErr := rows.Close(); closeErr != nil {
+	if closeErr := rows.Close(); closeErr != nil && err == nil {
 		return closeErr
 	}
 
-	// Check for row scan errors.
+	// Check for row scan and handler errors.
 	if err != nil {
 		return err
 	}
@@ -932,13 +2010,87 @@ func (stmt *statement) Query(ctx context.Context, db Executor, handler func(rows
 	return rows.Err()
 }
 
-// QueryAndClose executes the statement and releases all the resources that
-// can be reused to a pool. Do not call any Stmt methods after this call.
-// For every row of a returned dataset QueryAndClose executes a handler function.
-// If scan targets were set via To method calls, QueryAndClose method
-// executes rows.Scan right before calling a handler function.
-func (stmt *statement) QueryAndClose(ctx context.Context, db Executor, handler func(rows *sql.Rows)) error {
-	err := stmt.Query(ctx, db, handler)
+/*
+QueryFuncAndClose executes the statement via QueryFunc and releases
+all the resources that can be reused to a pool.
+Do not call any Stmt methods after this call.
+*/
+func (stmt *statement) QueryFuncAndClose(ctx context.Context, db Executor, handler func(rows *sql.Rows) error) error {
+	err := stmt.QueryFunc(ctx, db, handler)
+	stmt.Close()
+	return err
+}
+
+/*
+Iter executes the statement and returns a Cursor to iterate over returned rows:
+	cur, err := stmt.Iter(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer cur.Close()
+	for cur.Next() {
+		if err := cur.Scan(); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+The statement is to be kept open until the cursor is closed
+as Cursor.Scan uses variables bound via To method calls.
+*/
+func (stmt *statement) Iter(ctx context.Context, db Executor) (*Cursor, error) {
+	if err := stmt.check(); err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logStatement(ctx, db, stmt)
+	rows, err := db.QueryContext(ctx, stmt.String(), stmt.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	return &Cursor{rows: rows, dest: stmt.dest}, nil
+}
+
+/*
+QueryAll executes the statement and collects rows into a slice of structs.
+dest is a pointer to a slice of structs or struct pointers:
+	var users []User
+	err := sqlbuilder.From("users").
+		SelectStruct(&User{}).
+		Where("is_active").
+		QueryAll(ctx, db, &users)
+Columns are mapped to struct fields via db tags regardless of their order.
+A column not mapped to a field or a mapped field missing in the result set
+is reported as an error.
+Scan targets set via To method calls are not used.
+*/
+func (stmt *statement) QueryAll(ctx context.Context, db Executor, dest interface{}) error {
+	if err := stmt.check(); err != nil {
+		return err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logStatement(ctx, db, stmt)
+	rows, err := db.QueryContext(ctx, stmt.String(), stmt.Args()...)
+	if err != nil {
+		return err
+	}
+	err = scanStructs(rows, dest)
+	if closeErr := rows.Close(); closeErr != nil && err == nil {
+		return closeErr
+	}
+	return err
+}
+
+/*
+QueryAllAndClose executes the statement, collects rows into a slice of structs
+and releases all the resources that can be reused to a pool.
+Do not call any Stmt methods after this call.
+*/
+func (stmt *statement) QueryAllAndClose(ctx context.Context, db Executor, dest interface{}) error {
+	err := stmt.QueryAll(ctx, db, dest)
 	stmt.Close()
 	return err
 }
@@ -946,10 +2098,19 @@ func (stmt *statement) QueryAndClose(ctx context.Context, db Executor, handler f
 // QueryRow executes the statement via Executor methods
 // and scans values to variables bound via To method calls.
 func (stmt *statement) QueryRow(ctx context.Context, db Executor) error {
+	if err := stmt.check(); err != nil {
+		return err
+	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	row := db.QueryRowContext(ctx, stmt.String(), stmt.args...)
+	logStatement(ctx, db, stmt)
+	if stmt.dialect.Returning() == ReturningInto && stmt.hasPart(posReturning) {
+		// Returned values are passed back via out binds
+		_, err := db.ExecContext(ctx, stmt.String(), stmt.Args()...)
+		return err
+	}
+	row := db.QueryRowContext(ctx, stmt.String(), stmt.Args()...)
 
 	return row.Scan(stmt.dest...)
 }
@@ -968,10 +2129,14 @@ func (stmt *statement) QueryRowAndClose(ctx context.Context, db Executor) error
 
 // Exec executes the statement.
 func (stmt *statement) Exec(ctx context.Context, db Executor) (sql.Result, error) {
+	if err := stmt.check(); err != nil {
+		return nil, err
+	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	return db.ExecContext(ctx, stmt.String(), stmt.args...)
+	logStatement(ctx, db, stmt)
+	return db.ExecContext(ctx, stmt.String(), stmt.Args()...)
 }
 
 // ExecAndClose executes the statement and releases all the objects
@@ -1,7 +1,14 @@
 package sqlbuilder
 
 import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/valyala/bytebufferpool"
 )
@@ -26,6 +33,9 @@ func newStmt() interface{} {
 
 func getStmt(d Dialect) *statement {
 	stmt := stmtPool.Get().(*statement)
+	if d == nil {
+		d = DefaultDialect
+	}
 	stmt.dialect = d
 	stmt.buffer = getBuffer()
 	return stmt
@@ -45,6 +55,29 @@ func reuseStmt(stmt *statement) {
 		}
 		stmt.dest = stmt.dest[:0]
 	}
+	if len(stmt.outArgs) > 0 {
+		for n := range stmt.outArgs {
+			stmt.outArgs[n] = nil
+		}
+		stmt.outArgs = stmt.outArgs[:0]
+	}
+	for arg := range stmt.argIndex {
+		delete(stmt.argIndex, arg)
+	}
+	for name := range stmt.paramIndex {
+		delete(stmt.paramIndex, name)
+	}
+	for name := range stmt.params {
+		delete(stmt.params, name)
+	}
+	stmt.nested = false
+	stmt.returningDest = 0
+	for n := range stmt.errs {
+		stmt.errs[n] = nil
+	}
+	stmt.errs = stmt.errs[:0]
+	stmt.buildErr = nil
+	stmt.secretArgs = stmt.secretArgs[:0]
 	putBuffer(stmt.buffer)
 	stmt.buffer = nil
 	if stmt.sql != nil {
@@ -56,10 +89,8 @@ func reuseStmt(stmt *statement) {
 }
 
 var (
-	space            = []byte{' '}
-	placeholder      = []byte{'?'}
-	placeholderComma = []byte{'?', ','}
-	joinOn           = []byte{' ', 'O', 'N', ' ', '('}
+	space  = []byte{' '}
+	joinOn = []byte{' ', 'O', 'N', ' ', '('}
 )
 
 const (
@@ -69,6 +100,10 @@ const (
 	posInsert
 	posInsertFields
 	posValues
+	posUpsert
+	posMerge
+	posMergeUsing
+	posMergeWhen
 	posDelete
 	posUpdate
 	posSet
@@ -86,6 +121,21 @@ const (
 	posEnd
 )
 
+const (
+	// posUpdateFrom holds tables joined to an UPDATE statement
+	posUpdateFrom = posUpdate + 1
+	// posDeleteUsing holds tables joined to a DELETE statement
+	posDeleteUsing = posDelete + 1
+	// posOnConflict holds an ON CONFLICT clause followed by
+	// a DO UPDATE SET action, its WHERE condition or a DO NOTHING action
+	posOnConflict    = posUpsert
+	posDoUpdateSet   = posOnConflict + 1
+	posDoUpdateWhere = posOnConflict + 2
+	posDoNothing     = posOnConflict + 3
+	// posOnDuplicateKey holds an ON DUPLICATE KEY UPDATE clause
+	posOnDuplicateKey = posUpsert + 10
+)
+
 // addPart adds a clause or expression to a statement.
 func (stmt *statement) addPart(pos int, clause, expr string, args []interface{}, sep string) (index int) {
 	// Remember the position
@@ -112,6 +162,7 @@ loop:
 				// See if arguments are to be updated
 				if argLen > 0 {
 					copy(stmt.args[len(stmt.args)-argTail-part.argLen:], args)
+					stmt.Invalidate()
 				}
 				return i
 			}
@@ -184,6 +235,80 @@ loop:
 	return index
 }
 
+// insert adds an INSERT clause with a given verb to a statement
+func (stmt *statement) insert(verb, tableName string) {
+	stmt.addPart(posInsert, verb, tableName, nil, ", ")
+	stmt.addPart(posInsertFields-1, "(", "", nil, "")
+	stmt.addPart(posValues-1, ") VALUES (", "", nil, "")
+	stmt.addPart(posValues+1, ")", "", nil, "")
+	stmt.position = posInsertFields
+}
+
+// insertColumns returns the list of columns of an INSERT statement.
+func (stmt *statement) insertColumns() []string {
+	var columns []string
+	for _, part := range stmt.parts {
+		if part.position == posInsertFields {
+			for _, column := range strings.Split(string(stmt.buffer.B[part.bufLow:part.bufHigh]), ",") {
+				columns = append(columns, strings.TrimSpace(column))
+			}
+		}
+	}
+	return columns
+}
+
+// mergeBranch adds a WHEN branch to a MERGE statement.
+// Every branch takes 10 positions starting from posMergeWhen:
+// a WHEN clause, an UPDATE or INSERT action, a list of columns and values
+// of an INSERT action, and a DELETE action at offset 7.
+func (stmt *statement) mergeBranch(clause string, conds []Cond) {
+	branch := stmt.lastMergeBranch()
+	if branch == 0 {
+		branch = posMergeWhen
+	} else {
+		branch += 10
+	}
+	if branch >= posMergeWhen+100 {
+		stmt.fail(errors.New("sqlbuilder: a MERGE statement can have up to 10 WHEN branches"))
+		return
+	}
+	if len(conds) == 0 {
+		stmt.addPart(branch, clause, "", nil, "")
+		return
+	}
+	expr, args := condString(And(conds...), nil)
+	stmt.addPart(branch, clause+" AND", expr, args, "")
+}
+
+// lastMergeBranch returns the position of the last WHEN branch
+// of a MERGE statement or 0 if there is none.
+func (stmt *statement) lastMergeBranch() int {
+	branch := 0
+	for _, part := range stmt.parts {
+		if part.position >= posMergeWhen && part.position < posMergeWhen+100 {
+			branch = part.position - (part.position-posMergeWhen)%10
+		}
+	}
+	return branch
+}
+
+// removeParts removes all the parts at a given position
+// together with their arguments.
+func (stmt *statement) removeParts(pos int) {
+	parts := stmt.parts[:0]
+	argLow := 0
+	for _, part := range stmt.parts {
+		if part.position != pos {
+			parts = append(parts, part)
+			argLow += part.argLen
+			continue
+		}
+		stmt.args = append(stmt.args[:argLow], stmt.args[argLow+part.argLen:]...)
+	}
+	stmt.parts = parts
+	stmt.Invalidate()
+}
+
 // join adds a join clause to a SELECT statement
 func (stmt *statement) join(joinType, table, on string) (index int) {
 	buf := bytebufferpool.Get()
@@ -199,3 +324,480 @@ func (stmt *statement) join(joinType, table, on string) (index int) {
 
 	return index
 }
+
+// build renders the statement and the list of arguments
+// in the order their placeholders appear in the SQL.
+func (stmt *statement) build() {
+	stmt.sql = getBuffer()
+	stmt.outArgs = stmt.outArgs[:0]
+	stmt.secretArgs = stmt.secretArgs[:0]
+	stmt.buildErr = nil
+	for arg := range stmt.argIndex {
+		delete(stmt.argIndex, arg)
+	}
+	for name := range stmt.paramIndex {
+		delete(stmt.paramIndex, name)
+	}
+
+	// Some dialects render LIMIT and OFFSET out of the parts order,
+	// so look for their arguments in advance.
+	var limit, offset []interface{}
+	argLow := 0
+	for _, part := range stmt.parts {
+		switch part.position {
+		case posLimit:
+			limit = stmt.args[argLow : argLow+part.argLen]
+		case posOffset:
+			offset = stmt.args[argLow : argLow+part.argLen]
+		}
+		argLow += part.argLen
+	}
+	r := render{
+		pg:         stmt.dialect.Pagination(limit != nil, offset != nil),
+		limit:      limit,
+		offset:     offset,
+		multiTable: stmt.dialect.MultiTable(),
+	}
+	if r.pg.Top != "" && stmt.hasPart(posUnion) {
+		// TOP would limit the first SELECT of a UNION only,
+		// so limit the whole UNION with a zero offset instead
+		if !stmt.hasPart(posOrderBy) {
+			stmt.failBuild(errors.New("sqlbuilder: LIMIT of a UNION requires ORDER BY with the dialect"))
+		}
+		r.pg = stmt.dialect.Pagination(true, true)
+		r.offset = []interface{}{0}
+	}
+	// Nothing left to render at LIMIT and OFFSET positions for TOP
+	r.paginated = r.pg.Limit == "" && r.pg.Offset == ""
+	stmt.numbered = !stmt.nested && isNumbered(stmt.dialect)
+
+	if r.multiTable != MultiTableList && stmt.hasPart(posUpdateFrom) {
+		// Tables joined to an UPDATE statement go right after the SET clause
+		stmt.writeParts(&r, 0, posUpdate)
+		stmt.writeParts(&r, posUpdateFrom+1, posSet)
+		stmt.writeParts(&r, posUpdateFrom, posUpdateFrom)
+		stmt.writeParts(&r, posSet+1, math.MaxInt32)
+	} else {
+		stmt.writeParts(&r, 0, math.MaxInt32)
+	}
+	if stmt.dialect.Features()&FeatureMergeTerminator != 0 && !stmt.nested && stmt.hasPart(posMerge) {
+		// SQL Server requires MERGE statements to be terminated
+		stmt.sql.WriteByte(';')
+	}
+	stmt.checkDialect()
+	stmt.checkMerge()
+	// Nested statements pass secrets to outer ones as is
+	if !stmt.nested {
+		stmt.unwrapSecrets()
+	}
+}
+
+// checkDialect reports clauses the statement Dialect does not support.
+func (stmt *statement) checkDialect() {
+	upsert := stmt.dialect.Upsert()
+	for _, part := range stmt.parts {
+		switch part.position {
+		case posOnConflict:
+			if upsert != UpsertOnConflict && upsert != UpsertAny {
+				stmt.failBuild(errors.New("sqlbuilder: ON CONFLICT clause is not supported by the dialect"))
+			}
+		case posOnDuplicateKey:
+			if upsert != UpsertOnDuplicateKey && upsert != UpsertAny {
+				stmt.failBuild(errors.New("sqlbuilder: ON DUPLICATE KEY UPDATE clause is not supported by the dialect"))
+			}
+		case posReturning:
+			if stmt.dialect.Returning() == ReturningNone {
+				stmt.failBuild(errors.New("sqlbuilder: RETURNING clause is not supported by the dialect"))
+			}
+		}
+	}
+}
+
+// checkMerge records a build error for WHEN branches of a MERGE statement
+// having no action.
+func (stmt *statement) checkMerge() {
+	for _, part := range stmt.parts {
+		branch := part.position
+		if branch < posMergeWhen || branch >= posMergeWhen+100 || (branch-posMergeWhen)%10 != 0 {
+			continue
+		}
+		if !stmt.hasPart(branch+1) && !stmt.hasPart(branch+7) {
+			stmt.failBuild(errors.New("sqlbuilder: a WHEN branch of a MERGE statement requires a ThenUpdate, ThenDelete or ThenInsert call"))
+			return
+		}
+	}
+}
+
+// render holds a state of a statement being built.
+type render struct {
+	pg            Pagination
+	limit, offset []interface{}
+	paginated     bool
+	multiTable    MultiTableStyle
+	// pos is the position of the last rendered part
+	pos int
+	// updateTable holds the target table of a multi-table UPDATE statement
+	// to be rendered in its FROM clause
+	updateTable []byte
+	updateArgs  []interface{}
+}
+
+// writeParts renders statement parts with positions in [low, high] range.
+func (stmt *statement) writeParts(r *render, low, high int) {
+	argLow := 0
+	for _, part := range stmt.parts {
+		s := stmt.buffer.B[part.bufLow:part.bufHigh]
+		args := stmt.args[argLow : argLow+part.argLen]
+		argLow += part.argLen
+
+		if part.position < low || part.position > high {
+			continue
+		}
+		isPagination := part.position == posLimit || part.position == posOffset
+		if isPagination && r.paginated {
+			continue
+		}
+		isListed := r.multiTable != MultiTableUsing && part.position == posDeleteUsing ||
+			r.multiTable == MultiTableList && part.position == posUpdateFrom
+		// Separate clauses with spaces
+		first := part.position != r.pos
+		if len(stmt.sql.B) > 0 && first && !isListed && !isParenthesized(stmt.sql.B, s) {
+			stmt.sql.Write(space)
+		}
+		r.pos = part.position
+
+		switch pos := part.position; {
+		case isPagination:
+			r.paginated = true
+			if r.pg.OffsetFirst {
+				stmt.writeFragments(r.pg.Offset, r.offset, r.pg.Limit, r.limit)
+			} else {
+				stmt.writeFragments(r.pg.Limit, r.limit, r.pg.Offset, r.offset)
+			}
+		case first && (r.pg.Top != "" && (pos == posSelect || pos == posUpdate) || pos == posDelete):
+			k := verbLen(s)
+			stmt.sql.Write(s[:k])
+			if r.pg.Top != "" {
+				// TOP goes right after the statement verb
+				stmt.sql.Write(space)
+				stmt.writeExpr([]byte(r.pg.Top), r.limit)
+				r.pg.Top = ""
+			}
+			if pos == posDelete && r.multiTable != MultiTableUsing && stmt.hasPart(posDeleteUsing) {
+				stmt.sql.Write(space)
+				stmt.writeDeleteTarget(s[k:], args)
+			} else if pos == posUpdate && r.multiTable == MultiTableFrom && stmt.hasPart(posUpdateFrom) {
+				// The updated table goes to the FROM clause, leaving its alias behind
+				var alias []byte
+				r.updateTable, alias = splitTarget(s[k:])
+				r.updateArgs = args
+				stmt.sql.Write(space)
+				stmt.sql.Write(alias)
+			} else {
+				stmt.writeExpr(s[k:], args)
+			}
+		case (pos == posUpdateFrom || pos == posDeleteUsing) && first:
+			switch {
+			case isListed:
+				stmt.sql.WriteString(", ")
+			case pos == posUpdateFrom:
+				stmt.sql.WriteString("FROM ")
+				if r.updateTable != nil {
+					stmt.writeExpr(r.updateTable, r.updateArgs)
+					stmt.sql.WriteString(", ")
+				}
+			default:
+				stmt.sql.WriteString("USING ")
+			}
+			stmt.writeExpr(s, args)
+		case pos == posReturning && stmt.dialect.Returning() == ReturningInto && !stmt.nested:
+			stmt.writeExpr(s, args)
+			stmt.writeOutBinds()
+		default:
+			stmt.writeExpr(s, args)
+		}
+	}
+}
+
+// writeDeleteTarget writes a FROM clause of a multi-table DELETE statement
+// prepended by the name or alias of the table to delete rows from:
+//	DELETE t FROM table t, ...
+// s holds " FROM table" part of a DELETE FROM table clause.
+func (stmt *statement) writeDeleteTarget(s []byte, args []interface{}) {
+	table, alias := splitTarget(s)
+	stmt.sql.Write(alias)
+	stmt.sql.WriteString(" FROM ")
+	stmt.writeExpr(table, args)
+}
+
+// splitTarget returns a table expression of " FROM table t" or " table t"
+// part of a DELETE or UPDATE clause and the name or alias of the table.
+func splitTarget(s []byte) (table, alias []byte) {
+	table = bytes.TrimSpace(s)
+	if len(table) > 4 && bytes.EqualFold(table[:4], []byte("FROM")) {
+		table = bytes.TrimSpace(table[4:])
+	}
+	alias = table
+	if k := bytes.LastIndexAny(table, " \t\n"); k >= 0 {
+		alias = table[k+1:]
+	}
+	return table, alias
+}
+
+// writeOutBinds writes an INTO list of RETURNING clause for
+// value pointers bound by To method.
+func (stmt *statement) writeOutBinds() {
+	if stmt.returningDest >= len(stmt.dest) {
+		return
+	}
+	stmt.sql.WriteString(" INTO ")
+	for n, dest := range stmt.dest[stmt.returningDest:] {
+		if n > 0 {
+			stmt.sql.WriteString(", ")
+		}
+		stmt.outArgs = append(stmt.outArgs, sql.Out{Dest: dest})
+		stmt.sql.B = stmt.dialect.Placeholder(stmt.sql.B, len(stmt.outArgs))
+	}
+}
+
+// hasPart reports if the statement has a part at a given position.
+func (stmt *statement) hasPart(pos int) bool {
+	for _, part := range stmt.parts {
+		if part.position == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// writeExpr writes an SQL fragment with dialect specific placeholders
+// and appends its arguments to the list of statement arguments.
+// Named parameters bound via Bind method are replaced with placeholders too.
+// Slice arguments are expanded to lists of placeholders.
+// Quoted strings, quoted identifiers and comments are written as is.
+func (stmt *statement) writeExpr(s []byte, args []interface{}) {
+	if (len(args) == 0 || (!stmt.numbered && !hasList(args))) && len(stmt.params) == 0 {
+		stmt.sql.Write(s)
+		stmt.outArgs = append(stmt.outArgs, args...)
+		return
+	}
+	stmt.exprStart = len(stmt.sql.B)
+	start, n := 0, 0
+	for pos := 0; pos < len(s); pos++ {
+		switch c := s[pos]; c {
+		case '\\':
+			// \? is an escaped question mark, not a placeholder
+			if stmt.numbered && pos < len(s)-1 && s[pos+1] == '?' {
+				stmt.sql.Write(s[start:pos])
+				pos++
+				start = pos
+			}
+		case '?':
+			stmt.sql.Write(s[start:pos])
+			start = pos + 1
+			if n < len(args) {
+				start = stmt.bindValue(s, start, args[n])
+				pos = start - 1
+			} else {
+				stmt.placeholder(len(stmt.outArgs) + n - len(args) + 1)
+			}
+			n++
+		case '\'', '"', '`', '-', '/':
+			pos = skipLiteral(s, pos)
+		case ':', '@':
+			if len(stmt.params) == 0 {
+				continue
+			}
+			// Skip PostgreSQL type casts and SQL Server system variables
+			if pos < len(s)-1 && s[pos+1] == c {
+				pos++
+				continue
+			}
+			end := paramNameEnd(s, pos+1)
+			value, ok := stmt.params[string(s[pos+1:end])]
+			if end == pos+1 || !ok {
+				continue
+			}
+			stmt.sql.Write(s[start:pos])
+			if _, isList := listValue(value); isList {
+				start = stmt.bindValue(s, end, value)
+			} else {
+				stmt.bindParam(string(s[pos+1:end]), value)
+				start = end
+			}
+			pos = start - 1
+		}
+	}
+	stmt.sql.Write(s[start:])
+	if n < len(args) {
+		stmt.outArgs = append(stmt.outArgs, args[n:]...)
+	}
+	if len(args) > 0 && n != len(args) {
+		stmt.failBuild(fmt.Errorf("sqlbuilder: %q expects %d arguments, got %d", s, n, len(args)))
+	}
+}
+
+// bindValue binds a value of a placeholder followed by s[next:].
+// An empty list turns a whole IN (?) or NOT IN (?) predicate into
+// a condition that is always false or true respectively.
+// The operand of such a predicate must be a column, a function call
+// or a parenthesized expression, otherwise a build error is recorded.
+// It returns a position in s to continue from.
+func (stmt *statement) bindValue(s []byte, next int, value interface{}) int {
+	v, isList := listValue(value)
+	if !isList {
+		stmt.bind(value)
+		return next
+	}
+	b := stmt.sql.B
+	end := skipSpaces(s, next)
+	keyword, operand, not := inPredicate(b[stmt.exprStart:])
+	if end >= len(s) || s[end] != ')' {
+		keyword = -1
+	}
+	switch {
+	case keyword >= 0 && stmt.bindsArray(value, v.Len()):
+		// Bind a list as a single array argument
+		stmt.sql.B = b[:stmt.exprStart+keyword]
+		if not {
+			stmt.sql.WriteString("<> ALL(")
+		} else {
+			stmt.sql.WriteString("= ANY(")
+		}
+		stmt.bind(Array(v.Interface()))
+		stmt.sql.WriteByte(')')
+		return end + 1
+	case isArray(value) && keyword < 0:
+		// Any outside of IN (?) is always bound as a single array argument
+		if stmt.dialect.Features()&FeatureArrays == 0 {
+			stmt.failBuild(fmt.Errorf("sqlbuilder: arrays bound by Any in %q are not supported by the dialect", s))
+		}
+		stmt.bind(Array(v.Interface()))
+		return next
+	case v.Len() > 0:
+		stmt.bind(value)
+		return next
+	case keyword < 0:
+		// Not an IN predicate, bind NULL instead of an empty list
+		stmt.sql.WriteString("NULL")
+		return next
+	case operand < 0:
+		stmt.failBuild(fmt.Errorf("sqlbuilder: empty list in %q needs a column or a parenthesized operand", s))
+		stmt.sql.WriteString("NULL")
+		return next
+	}
+	stmt.sql.B = b[:stmt.exprStart+operand]
+	if not {
+		stmt.sql.WriteString("1=1")
+	} else {
+		stmt.sql.WriteString("1=0")
+	}
+	return end + 1
+}
+
+// bindsArray reports if a list is to be bound as a single array argument.
+func (stmt *statement) bindsArray(value interface{}, length int) bool {
+	if stmt.dialect.Features()&FeatureArrays == 0 {
+		return false
+	}
+	if isArray(value) {
+		return true
+	}
+	threshold := int(atomic.LoadInt32(&arrayThreshold))
+	return threshold > 0 && length >= threshold
+}
+
+// isArray reports if a value is wrapped with Any.
+func isArray(value interface{}) bool {
+	_, ok := value.(arrayList)
+	return ok
+}
+
+// bind appends an argument to the list of statement arguments
+// and writes a placeholder for it.
+// Slices are expanded and every item is bound separately.
+// Dialects with ReuseEqual style bind equal arguments once.
+func (stmt *statement) bind(arg interface{}) (argNo int) {
+	if v, isList := listValue(arg); isList {
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				stmt.sql.WriteString(", ")
+			}
+			stmt.bind(v.Index(i).Interface())
+		}
+		return 0
+	}
+	if stmt.numbered && stmt.dialect.Reuse() == ReuseEqual && isScalar(arg) {
+		if stmt.argIndex == nil {
+			stmt.argIndex = make(map[interface{}]int)
+		}
+		argNo, ok := stmt.argIndex[arg]
+		if !ok {
+			stmt.outArgs = append(stmt.outArgs, arg)
+			argNo = len(stmt.outArgs)
+			stmt.argIndex[arg] = argNo
+		}
+		stmt.placeholder(argNo)
+		return argNo
+	}
+	stmt.outArgs = append(stmt.outArgs, arg)
+	argNo = len(stmt.outArgs)
+	stmt.placeholder(argNo)
+	return argNo
+}
+
+// bindParam binds a named parameter value.
+// Dialects with ReuseNamed style bind each parameter once.
+func (stmt *statement) bindParam(name string, value interface{}) {
+	if !stmt.numbered || stmt.dialect.Reuse() < ReuseNamed {
+		stmt.bind(value)
+		return
+	}
+	if argNo, ok := stmt.paramIndex[name]; ok {
+		stmt.placeholder(argNo)
+		return
+	}
+	if stmt.paramIndex == nil {
+		stmt.paramIndex = make(map[string]int)
+	}
+	stmt.paramIndex[name] = stmt.bind(value)
+}
+
+// placeholder writes a placeholder for argNo-th argument.
+// Nested statements keep ? placeholders to be numbered by outer statements.
+func (stmt *statement) placeholder(argNo int) {
+	if stmt.numbered {
+		stmt.sql.B = stmt.dialect.Placeholder(stmt.sql.B, argNo)
+	} else {
+		stmt.sql.WriteByte('?')
+	}
+}
+
+// writeFragments writes a pair of LIMIT/OFFSET fragments separated by a space.
+func (stmt *statement) writeFragments(first string, firstArgs []interface{}, second string, secondArgs []interface{}) {
+	if first != "" {
+		stmt.writeExpr([]byte(first), firstArgs)
+		if second != "" {
+			stmt.sql.Write(space)
+		}
+	}
+	if second != "" {
+		stmt.writeExpr([]byte(second), secondArgs)
+	}
+}
+
+// nest prepares a query to be embedded into the statement.
+// The query is rendered using the statement dialect, but its ? placeholders
+// are kept as is to be numbered along with the statement ones.
+func (stmt *statement) nest(query Statement) {
+	if q, ok := query.(*statement); ok {
+		q.dialect = stmt.dialect
+		q.nested = true
+		q.Invalidate()
+	} else if query.GetDialect() != DefaultDialect {
+		query.SetDialect(DefaultDialect)
+	}
+	if err := query.Err(); err != nil {
+		stmt.fail(err)
+	}
+}
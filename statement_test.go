@@ -1,6 +1,7 @@
 package sqlbuilder_test
 
 import (
+	"database/sql"
 	"fmt"
 	"sqlbuilder"
 	"testing"
@@ -256,3 +257,451 @@ func TestLimit(t *testing.T) {
 	assert.Equal(t, "SELECT id FROM items WHERE id > ? LIMIT ?", q.String())
 	assert.Equal(t, []interface{}{42, 20}, q.Args())
 }
+
+func TestMySQLOnDuplicateKeyUpdate(t *testing.T) {
+	q := sqlbuilder.WithDialect(sqlbuilder.MySQL).
+		InsertInto("counters").
+		Set("name", "home").
+		Set("hits", 1).
+		OnDuplicateKeyUpdate().
+		SetExpr("hits", "hits + ?", 1).
+		Set("updated_at", "2020-01-01")
+	defer q.Close()
+	assert.Equal(t, "INSERT INTO counters (name, hits) VALUES (?, ?) ON DUPLICATE KEY UPDATE hits=hits + ?, updated_at=?", q.String())
+	assert.Equal(t, []interface{}{"home", 1, 1, "2020-01-01"}, q.Args())
+}
+
+func TestMySQLOffset(t *testing.T) {
+	q := sqlbuilder.WithDialect(sqlbuilder.MySQL).From("items").Select("id").Offset(20)
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM items LIMIT 18446744073709551615 OFFSET ?", q.String())
+	assert.Equal(t, []interface{}{20}, q.Args())
+
+	q2 := sqlbuilder.WithDialect(sqlbuilder.MySQL).From("items").Select("id").Paginate(3, 10)
+	defer q2.Close()
+	assert.Equal(t, "SELECT id FROM items LIMIT ? OFFSET ?", q2.String())
+	assert.Equal(t, []interface{}{10, 20}, q2.Args())
+}
+
+func TestSQLServerPlaceholders(t *testing.T) {
+	q := sqlbuilder.WithDialect(sqlbuilder.SQLServer).
+		From("items").
+		Select("id").
+		Where("price > ?", 10).
+		Where("name <> ?", "x")
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM items WHERE price > @p1 AND name <> @p2", q.String())
+	assert.Equal(t, []interface{}{10, "x"}, q.Args())
+}
+
+func TestSQLServerPagination(t *testing.T) {
+	q := sqlbuilder.WithDialect(sqlbuilder.SQLServer).
+		From("items").
+		Select("DISTINCT id").
+		Where("price > ?", 10).
+		Limit(5)
+	defer q.Close()
+	assert.Equal(t, "SELECT DISTINCT TOP (@p1) id FROM items WHERE price > @p2", q.String())
+	assert.Equal(t, []interface{}{5, 10}, q.Args())
+
+	q2 := sqlbuilder.WithDialect(sqlbuilder.SQLServer).
+		From("items").
+		Select("id").
+		Where("price > ?", 10).
+		OrderBy("id").
+		Paginate(3, 20)
+	defer q2.Close()
+	assert.Equal(t, "SELECT id FROM items WHERE price > @p1 ORDER BY id OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY", q2.String())
+	assert.Equal(t, []interface{}{10, 40, 20}, q2.Args())
+}
+
+func TestSQLServerUnionLimit(t *testing.T) {
+	q := sqlbuilder.WithDialect(sqlbuilder.SQLServer).
+		From("tasks").
+		Select("id").
+		Where("status = ?", "new").
+		Union(true, sqlbuilder.From("archive").Select("id")).
+		OrderBy("id").
+		Limit(5)
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM tasks WHERE status = @p1 UNION ALL SELECT id FROM archive ORDER BY id OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY", q.String())
+	assert.Equal(t, []interface{}{"new", 0, 5}, q.Args())
+	assert.NoError(t, q.Err())
+
+	q2 := sqlbuilder.WithDialect(sqlbuilder.SQLServer).
+		From("tasks").
+		Select("id").
+		Union(true, sqlbuilder.From("archive").Select("id")).
+		Limit(5)
+	defer q2.Close()
+	assert.EqualError(t, q2.Err(), "sqlbuilder: LIMIT of a UNION requires ORDER BY with the dialect")
+}
+
+func TestSQLServerSubQuery(t *testing.T) {
+	q := sqlbuilder.WithDialect(sqlbuilder.SQLServer).
+		From("users u").
+		Select("email").
+		Where("registered > ?", "2019-01-01").
+		SubQuery("EXISTS (", ")",
+			sqlbuilder.From("orders").
+				Select("id").
+				Where("user_id = u.id").
+				Where("amount > ?", 100).
+				Limit(1))
+	defer q.Close()
+	assert.Equal(t, "SELECT email FROM users u WHERE registered > @p1 AND EXISTS (SELECT TOP (@p2) id FROM orders WHERE user_id = u.id AND amount > @p3)", q.String())
+	assert.Equal(t, []interface{}{"2019-01-01", 1, 100}, q.Args())
+}
+
+func TestOraclePlaceholders(t *testing.T) {
+	q := sqlbuilder.WithDialect(sqlbuilder.Oracle).
+		From("items").
+		Select("id").
+		Where("code \\? ?", "x").
+		Where("price > ?", 10).
+		OrderBy("id").
+		Paginate(2, 10)
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM items WHERE code ? :1 AND price > :2 ORDER BY id OFFSET :3 ROWS FETCH FIRST :4 ROWS ONLY", q.String())
+	assert.Equal(t, []interface{}{"x", 10, 10, 10}, q.Args())
+}
+
+func TestOracleReturningInto(t *testing.T) {
+	var (
+		id   int64
+		name string
+	)
+	q := sqlbuilder.WithDialect(sqlbuilder.Oracle).
+		InsertInto("users").
+		Set("name", "John").
+		Set("email", "john@example.com").
+		Returning("id, name").To(&id, &name)
+	defer q.Close()
+	assert.Equal(t, "INSERT INTO users (name, email) VALUES (:1, :2) RETURNING id, name INTO :3, :4", q.String())
+	assert.Equal(t, []interface{}{"John", "john@example.com", sql.Out{Dest: &id}, sql.Out{Dest: &name}}, q.Args())
+}
+
+func TestSQLitePlaceholders(t *testing.T) {
+	q := sqlbuilder.WithDialect(sqlbuilder.SQLite).
+		From("events").
+		Select("id").
+		Where("created_by = ? OR updated_by = ?", 7, 7).
+		Where("kind = ?", "login").
+		Offset(10)
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM events WHERE created_by = ?1 OR updated_by = ?1 AND kind = ?2 LIMIT -1 OFFSET ?3", q.String())
+	assert.Equal(t, []interface{}{7, "login", 10}, q.Args())
+}
+
+func TestSQLiteInsertOr(t *testing.T) {
+	q := sqlbuilder.WithDialect(sqlbuilder.SQLite).
+		InsertOrReplace("users").
+		Set("id", 1).
+		Set("name", "John").
+		Returning("id")
+	defer q.Close()
+	assert.Equal(t, "INSERT OR REPLACE INTO users (id, name) VALUES (?1, ?2) RETURNING id", q.String())
+	assert.Equal(t, []interface{}{1, "John"}, q.Args())
+
+	q2 := sqlbuilder.WithDialect(sqlbuilder.SQLite).
+		InsertOrIgnore("tags").
+		Set("name", []byte("go"))
+	defer q2.Close()
+	assert.Equal(t, "INSERT OR IGNORE INTO tags (name) VALUES (?1)", q2.String())
+	assert.Equal(t, []interface{}{[]byte("go")}, q2.Args())
+}
+
+func TestSQLiteUnhashableArgs(t *testing.T) {
+	named := sql.Named("x", []byte("z"))
+	array := sqlbuilder.Array([]int{1, 2})
+	q := sqlbuilder.WithDialect(sqlbuilder.SQLite).
+		InsertInto("t").
+		Set("a", named).
+		Set("b", array).
+		Set("c", array).
+		Set("d", "x").
+		Set("e", "x")
+	defer q.Close()
+	assert.NotPanics(t, func() {
+		assert.Equal(t, "INSERT INTO t (a, b, c, d, e) VALUES (?1, ?2, ?3, ?4, ?4)", q.String())
+	})
+	assert.Equal(t, []interface{}{named, array, array, "x"}, q.Args())
+}
+
+func TestNamedParams(t *testing.T) {
+	from, to := "2020-01-01", "2020-02-01"
+	q := sqlbuilder.UsingPostgresql().
+		From("orders").
+		Select("id, created_at::date").
+		Where("status = ?", "paid").
+		Where("created_at BETWEEN :from AND :to").
+		Where("updated_at < :to").
+		Bind(map[string]interface{}{"from": from, "to": to})
+	defer q.Close()
+	assert.Equal(t, "SELECT id, created_at::date FROM orders WHERE status = $1 AND created_at BETWEEN $2 AND $3 AND updated_at < $3", q.String())
+	assert.Equal(t, []interface{}{"paid", from, to}, q.Args())
+
+	q.SetDialect(sqlbuilder.DefaultDialect)
+	assert.Equal(t, "SELECT id, created_at::date FROM orders WHERE status = ? AND created_at BETWEEN ? AND ? AND updated_at < ?", q.String())
+	assert.Equal(t, []interface{}{"paid", from, to, to}, q.Args())
+}
+
+func TestNamedParamsStruct(t *testing.T) {
+	type base struct {
+		Owner int64 `db:"owner"`
+	}
+	type filter struct {
+		base
+		Kind   string `db:"kind"`
+		Secret string `db:"-"`
+		Note   string
+	}
+	q := sqlbuilder.WithDialect(sqlbuilder.SQLServer).
+		From("docs").
+		Select("id").
+		Where("owner_id = @owner AND kind = @kind AND @@ROWCOUNT > 0 AND note = @Note").
+		Bind(&filter{base: base{Owner: 7}, Kind: "pdf", Secret: "x", Note: "n"})
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM docs WHERE owner_id = @p1 AND kind = @p2 AND @@ROWCOUNT > 0 AND note = @Note", q.String())
+	assert.Equal(t, []interface{}{int64(7), "pdf"}, q.Args())
+}
+
+func TestNamedParamsInLiterals(t *testing.T) {
+	q := sqlbuilder.WithDialect(sqlbuilder.SQLServer).
+		From("users").
+		Select("id").
+		Where("email <> 'a@example.com' AND x = @example -- @example\n").
+		Where("note <> ':example' /* :example */ AND kind = :example").
+		Bind(map[string]interface{}{"example": 1})
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM users WHERE email <> 'a@example.com' AND x = @p1 -- @example\n "+
+		"AND note <> ':example' /* :example */ AND kind = @p1", q.String())
+	assert.Equal(t, []interface{}{1}, q.Args())
+}
+
+func TestNamedParamsSubQuery(t *testing.T) {
+	q := sqlbuilder.UsingPostgresql().
+		From("users u").
+		Select("email").
+		Where("u.region = :region").
+		SubQuery("EXISTS (", ")",
+			sqlbuilder.From("orders").
+				Select("id").
+				Where("user_id = u.id").
+				Where("region = :region")).
+		Bind(map[string]interface{}{"region": "eu"})
+	defer q.Close()
+	assert.Equal(t, "SELECT email FROM users u WHERE u.region = $1 AND EXISTS (SELECT id FROM orders WHERE user_id = u.id AND region = $1)", q.String())
+	assert.Equal(t, []interface{}{"eu"}, q.Args())
+}
+
+func TestSliceExpansion(t *testing.T) {
+	q := sqlbuilder.UsingPostgresql().
+		From("tasks").
+		Select("id").
+		Where("status IN (?) AND owner_id NOT IN (?)", []string{"new", "wip"}, []int64{7}).
+		Where("payload <> ?", []byte("x")).
+		Having("COUNT(*) IN (?)", [2]int{1, 2})
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM tasks WHERE status IN ($1, $2) AND owner_id NOT IN ($3) AND payload <> $4 HAVING COUNT(*) IN ($5, $6)", q.String())
+	assert.Equal(t, []interface{}{"new", "wip", int64(7), []byte("x"), 1, 2}, q.Args())
+}
+
+func TestEmptySliceExpansion(t *testing.T) {
+	q := sqlbuilder.From("tasks t").
+		Select("id").
+		Where("t.status IN (?) AND lower(t.owner) NOT IN (?) AND id > ?", []string{}, []string(nil), 10).
+		Where("id").In([]int{})
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM tasks t WHERE 1=0 AND 1=1 AND id > ? AND 1=0", q.String())
+	assert.Equal(t, []interface{}{10}, q.Args())
+
+	q2 := sqlbuilder.Update("tasks").
+		SetExpr("tags", "ARRAY[?]", []string{}).
+		Where("id IN (:ids)").
+		Bind(map[string]interface{}{"ids": []int{1, 2}})
+	defer q2.Close()
+	assert.Equal(t, "UPDATE tasks SET tags=ARRAY[NULL] WHERE id IN (?, ?)", q2.String())
+	assert.Equal(t, []interface{}{1, 2}, q2.Args())
+}
+
+func TestEmptySliceOperand(t *testing.T) {
+	q := sqlbuilder.From("t").
+		Select("id").
+		Where("(t.a + 1) IN (?) OR b NOT IN (?)", []int{}, []int{})
+	defer q.Close()
+	assert.Equal(t, "SELECT id FROM t WHERE 1=0 OR 1=1", q.String())
+	assert.NoError(t, q.Err())
+
+	q2 := sqlbuilder.From("t").Select("id").Where("t.a + 1 IN (?)", []int{})
+	defer q2.Close()
+	assert.EqualError(t, q2.Err(), `sqlbuilder: empty list in "WHERE t.a + 1 IN (?)" needs a column or a parenthesized operand`)
+
+	q3 := sqlbuilder.UsingPostgresql().From("t").Select("id").Where("status::text NOT IN (?)", []string{})
+	defer q3.Close()
+	assert.EqualError(t, q3.Err(), `sqlbuilder: empty list in "WHERE status::text NOT IN (?)" needs a column or a parenthesized operand`)
+}
+
+func TestClauseSliceExpansion(t *testing.T) {
+	q := sqlbuilder.WithDialect(sqlbuilder.SQLite).
+		From("tasks").
+		Select("id").
+		Expr("priority IN (?) AS urgent", []int{1, 2}).
+		Clause("ORDER BY status IN (?) DESC", []string{"new", "wip"})
+	defer q.Close()
+	assert.Equal(t, "SELECT id, priority IN (?1, ?2) AS urgent FROM tasks ORDER BY status IN (?3, ?4) DESC", q.String())
+	assert.Equal(t, []interface{}{1, 2, "new", "wip"}, q.Args())
+}
+
+func TestInsertValues(t *testing.T) {
+	q := sqlbuilder.UsingPostgresql().
+		InsertInto("points").
+		Columns("x", "y").
+		Columns("label").
+		Values(1, 2, "a").
+		Values(3, 4, "b").
+		Returning("id")
+	defer q.Close()
+	assert.Equal(t, "INSERT INTO points (x, y, label) VALUES ($1, $2, $3), ($4, $5, $6) RETURNING id", q.String())
+	assert.Equal(t, []interface{}{1, 2, "a", 3, 4, "b"}, q.Args())
+}
+
+func TestInsertFromSelect(t *testing.T) {
+	q := sqlbuilder.UsingPostgresql().
+		InsertInto("archive").
+		Columns("id", "payload").
+		FromSelect(sqlbuilder.From("events").
+			Select("id, payload").
+			Where("kind = ?", "click").
+			Where("created_at < ?", 100)).
+		Returning("id")
+	defer q.Close()
+	assert.Equal(t, "INSERT INTO archive (id, payload) SELECT id, payload FROM events WHERE kind = $1 AND created_at < $2 RETURNING id", q.String())
+	assert.Equal(t, []interface{}{"click", 100}, q.Args())
+
+	q2 := sqlbuilder.InsertInto("archive").
+		FromSelect(sqlbuilder.From("events").Select("*").Where("id = ?", 1))
+	defer q2.Close()
+	assert.Equal(t, "INSERT INTO archive SELECT * FROM events WHERE id = ?", q2.String())
+	assert.Equal(t, []interface{}{1}, q2.Args())
+}
+
+func TestOnConflict(t *testing.T) {
+	q := sqlbuilder.UsingPostgresql().
+		InsertInto("counters").
+		Set("name", "home").
+		Set("hits", 1).
+		Returning("hits").
+		OnConflict("name").
+		DoUpdateSet("name").
+		DoUpdateSetExpr("hits", "counters.hits + ?", 5).
+		Set("updated_at", 10).
+		DoUpdateWhere("counters.hits < ?", 100)
+	defer q.Close()
+	assert.Equal(t, "INSERT INTO counters (name, hits) VALUES ($1, $2) ON CONFLICT (name) DO UPDATE SET name=EXCLUDED.name, hits=counters.hits + $3, updated_at=$4 WHERE counters.hits < $5 RETURNING hits", q.String())
+	assert.Equal(t, []interface{}{"home", 1, 5, 10, 100}, q.Args())
+
+	q2 := sqlbuilder.WithDialect(sqlbuilder.SQLite).
+		InsertInto("tags").
+		Set("name", "go").
+		OnConflict().
+		DoNothing()
+	defer q2.Close()
+	assert.Equal(t, "INSERT INTO tags (name) VALUES (?1) ON CONFLICT DO NOTHING", q2.String())
+
+	q3 := sqlbuilder.UsingPostgresql().InsertInto("t").Set("a", 1).OnConflict("a").Set("b", 2)
+	defer q3.Close()
+	assert.Equal(t, "INSERT INTO t (a) VALUES ($1) ON CONFLICT (a)", q3.String())
+	assert.EqualError(t, q3.Err(), `sqlbuilder: Set("b") after OnConflict requires a DoUpdateSet call`)
+
+	q4 := sqlbuilder.UsingPostgresql().InsertInto("t").Set("a", 1).OnConflict("a").DoNothing().Set("b", 2)
+	defer q4.Close()
+	assert.Equal(t, "INSERT INTO t (a) VALUES ($1) ON CONFLICT (a) DO NOTHING", q4.String())
+	assert.EqualError(t, q4.Err(), `sqlbuilder: Set("b") can not follow DoNothing`)
+}
+
+func TestUpdateFrom(t *testing.T) {
+	build := func(d sqlbuilder.Dialect) sqlbuilder.Statement {
+		return sqlbuilder.WithDialect(d).
+			Update("orders o").
+			UpdateFrom("customers c").
+			Set("status", "vip").
+			SetExpr("discount", "c.discount * ?", 2).
+			Where("c.id = o.customer_id").
+			Where("c.level > ?", 3)
+	}
+	q := build(sqlbuilder.PostgreSQL)
+	defer q.Close()
+	assert.Equal(t, "UPDATE orders o SET status=$1, discount=c.discount * $2 FROM customers c WHERE c.id = o.customer_id AND c.level > $3", q.String())
+	assert.Equal(t, []interface{}{"vip", 2, 3}, q.Args())
+
+	q2 := build(sqlbuilder.MySQL)
+	defer q2.Close()
+	assert.Equal(t, "UPDATE orders o, customers c SET status=?, discount=c.discount * ? WHERE c.id = o.customer_id AND c.level > ?", q2.String())
+
+	q4 := build(sqlbuilder.SQLServer).Limit(5)
+	defer q4.Close()
+	assert.Equal(t, "UPDATE TOP (@p1) o SET status=@p2, discount=c.discount * @p3 FROM orders o, customers c WHERE c.id = o.customer_id AND c.level > @p4", q4.String())
+	assert.Equal(t, []interface{}{5, "vip", 2, 3}, q4.Args())
+
+	q3 := sqlbuilder.UsingPostgresql().
+		Update("orders o").
+		UpdateFrom("(SELECT id FROM customers WHERE level > ?) c", 3).
+		Set("status", "vip").
+		Where("c.id = o.customer_id")
+	defer q3.Close()
+	assert.Equal(t, "UPDATE orders o SET status=$1 FROM (SELECT id FROM customers WHERE level > $2) c WHERE c.id = o.customer_id", q3.String())
+	assert.Equal(t, []interface{}{"vip", 3}, q3.Args())
+}
+
+func TestDeleteUsing(t *testing.T) {
+	build := func(d sqlbuilder.Dialect) sqlbuilder.Statement {
+		return sqlbuilder.WithDialect(d).
+			DeleteFrom("orders o").
+			DeleteUsing("customers c").
+			Where("c.id = o.customer_id AND c.level < ?", 1)
+	}
+	q := build(sqlbuilder.PostgreSQL)
+	defer q.Close()
+	assert.Equal(t, "DELETE FROM orders o USING customers c WHERE c.id = o.customer_id AND c.level < $1", q.String())
+
+	q2 := build(sqlbuilder.MySQL)
+	defer q2.Close()
+	assert.Equal(t, "DELETE o FROM orders o, customers c WHERE c.id = o.customer_id AND c.level < ?", q2.String())
+
+	q3 := build(sqlbuilder.SQLServer).Limit(10)
+	defer q3.Close()
+	assert.Equal(t, "DELETE TOP (@p1) o FROM orders o, customers c WHERE c.id = o.customer_id AND c.level < @p2", q3.String())
+	assert.Equal(t, []interface{}{10, 1}, q3.Args())
+}
+
+func TestMergeInto(t *testing.T) {
+	q := sqlbuilder.UsingPostgresql().
+		MergeInto("stock t").
+		Using(sqlbuilder.From("deliveries").
+			Select("item_id, SUM(qty) AS qty").
+			Where("day = ?", 7).
+			GroupBy("item_id"), "d", "d.item_id = t.item_id").
+		WhenMatched(sqlbuilder.Raw("t.qty + d.qty = 0")).ThenDelete().
+		WhenMatched().ThenUpdate().
+		SetExpr("qty", "t.qty + d.qty").
+		Set("updated_by", "robot").
+		WhenNotMatched(sqlbuilder.Gt("d.qty", 0)).ThenInsert().
+		SetExpr("item_id", "d.item_id").
+		SetExpr("qty", "d.qty").
+		Set("updated_by", "robot")
+	defer q.Close()
+	assert.Equal(t, "MERGE INTO stock t USING (SELECT item_id, SUM(qty) AS qty FROM deliveries WHERE day = $1 GROUP BY item_id) d ON d.item_id = t.item_id "+
+		"WHEN MATCHED AND (t.qty + d.qty = 0) THEN DELETE "+
+		"WHEN MATCHED THEN UPDATE SET qty=t.qty + d.qty, updated_by=$2 "+
+		"WHEN NOT MATCHED AND d.qty > $3 THEN INSERT (item_id, qty, updated_by) VALUES (d.item_id, d.qty, $4)", q.String())
+	assert.Equal(t, []interface{}{7, "robot", 0, "robot"}, q.Args())
+
+	q2 := sqlbuilder.WithDialect(sqlbuilder.SQLServer).
+		MergeInto("stock t").
+		Using("deliveries", "d", "d.item_id = t.item_id AND d.day = ?", 7).
+		WhenMatched().ThenUpdate().
+		SetExpr("qty", "t.qty + d.qty")
+	defer q2.Close()
+	assert.Equal(t, "MERGE INTO stock t USING deliveries d ON d.item_id = t.item_id AND d.day = @p1 WHEN MATCHED THEN UPDATE SET qty=t.qty + d.qty;", q2.String())
+}
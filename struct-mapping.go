@@ -0,0 +1,301 @@
+package sqlbuilder
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// structField describes a struct field mapped to a column via db tag.
+type structField struct {
+	column string
+	index  []int
+	// omitEmpty is set by omitempty tag option
+	omitEmpty bool
+	// readOnly is set by readonly tag option
+	readOnly bool
+	// pk is set by pk tag option
+	pk bool
+}
+
+// structFieldsCache holds []structField per struct type
+var structFieldsCache sync.Map
+
+/*
+structFields returns the list of fields a struct type maps to columns.
+Fields are mapped using db tags:
+	type User struct {
+		ID    int64  `db:"id"`
+		Email string `db:"email"`
+		Notes string `db:"-"`
+	}
+Fields of embedded structs are mapped as if they belonged to the outer struct.
+Untagged and unexported fields are skipped.
+Tag options omitempty, readonly and pk are used by SetStruct.
+*/
+func structFields(t reflect.Type) []structField {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.([]structField)
+	}
+	fields := appendStructFields(nil, t, nil)
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+func appendStructFields(fields []structField, t reflect.Type, index []int) []structField {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("db")
+		options := strings.Split(tag, ",")
+		name := options[0]
+		if name == "-" {
+			continue
+		}
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && !hasTag && ft.Kind() == reflect.Struct {
+			fields = appendStructFields(fields, ft, fieldIndex)
+			continue
+		}
+		if name == "" || f.PkgPath != "" {
+			continue
+		}
+		field := structField{column: name, index: fieldIndex}
+		for _, option := range options[1:] {
+			switch strings.TrimSpace(option) {
+			case "omitempty":
+				field.omitEmpty = true
+			case "readonly":
+				field.readOnly = true
+			case "pk":
+				field.pk = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// fieldByIndex returns a nested struct field.
+// ok is false if an embedded struct pointer on the way is nil.
+func fieldByIndex(v reflect.Value, index []int) (field reflect.Value, ok bool) {
+	for n, i := range index {
+		if n > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return v, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+// fieldAddr returns a pointer to a nested struct field.
+// Nil embedded struct pointers on the way are allocated.
+// ok is false if a nil pointer can not be set as its field is unexported.
+func fieldAddr(v reflect.Value, index []int) (ptr interface{}, ok bool) {
+	for n, i := range index {
+		if n > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return nil, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v.Addr().Interface(), true
+}
+
+// structDest returns mapped columns of a struct dest points to
+// and pointers to the corresponding fields.
+// ok is false if dest is not a pointer to a struct.
+func structDest(dest interface{}) (columns []string, ptrs []interface{}, ok bool) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, nil, false
+	}
+	v = v.Elem()
+	fields := structFields(v.Type())
+	columns = make([]string, 0, len(fields))
+	ptrs = make([]interface{}, 0, len(fields))
+	for _, field := range fields {
+		if ptr, ok := fieldAddr(v, field.index); ok {
+			columns = append(columns, field.column)
+			ptrs = append(ptrs, ptr)
+		}
+	}
+	return columns, ptrs, true
+}
+
+/*
+Fields returns pointers to struct fields mapped to columns via db tags.
+dest must be a pointer to a struct.
+Pointers follow the order fields are declared in, so they can be passed
+to To method of a statement selecting columns in the same order:
+	stmt.Select("id, email").To(sqlbuilder.Fields(&user)...)
+Use SelectStruct to add both columns and pointers at once.
+*/
+func Fields(dest interface{}) []interface{} {
+	_, ptrs, _ := structDest(dest)
+	return ptrs
+}
+
+// setOptions holds options of SetStruct method.
+type setOptions struct {
+	only     []string
+	hasOnly  bool
+	skipZero bool
+}
+
+// SetOption is an option of SetStruct method.
+type SetOption func(o *setOptions)
+
+// Only limits columns set by SetStruct to the listed ones.
+// Listed columns are set even if their values are empty.
+// Only with no columns makes SetStruct set nothing.
+func Only(columns ...string) SetOption {
+	return func(o *setOptions) {
+		o.only = append(o.only, columns...)
+		o.hasOnly = true
+	}
+}
+
+// SkipZero makes SetStruct skip all the fields having zero values
+// as if they were tagged with omitempty.
+func SkipZero() SetOption {
+	return func(o *setOptions) {
+		o.skipZero = true
+	}
+}
+
+// setStruct calls set for every field of a struct to be inserted or updated.
+func setStruct(value interface{}, isUpdate bool, opts []SetOption, set func(column string, value interface{})) {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	var o setOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	for _, field := range structFields(v.Type()) {
+		if field.readOnly || (field.pk && isUpdate) {
+			continue
+		}
+		fv, ok := fieldByIndex(v, field.index)
+		if !ok {
+			continue
+		}
+		if o.hasOnly {
+			if !containsString(o.only, field.column) {
+				continue
+			}
+		} else if (field.omitEmpty || o.skipZero || field.pk) && fv.IsZero() {
+			// A zero primary key is left to be generated by a database
+			continue
+		}
+		set(field.column, fv.Interface())
+	}
+}
+
+// containsString reports if a list contains a string.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// scanStructs fills a slice of structs or struct pointers dest points to with rows.
+// Columns are mapped to fields by name, so their order does not matter.
+func scanStructs(rows *sql.Rows, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("sqlbuilder: can not scan rows into %T, a pointer to a slice expected", dest)
+	}
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	structType := elemType
+	if isPtr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("sqlbuilder: can not scan rows into %T, a slice of structs expected", dest)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	indexes, err := columnFields(columns, structType)
+	if err != nil {
+		return err
+	}
+
+	slice.Set(slice.Slice(0, 0))
+	for rows.Next() {
+		item := reflect.New(structType)
+		if err := scanStruct(rows, item.Elem(), columns, indexes); err != nil {
+			return err
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, item))
+		} else {
+			slice.Set(reflect.Append(slice, item.Elem()))
+		}
+	}
+	return rows.Err()
+}
+
+// columnFields maps result set columns to fields of a struct type.
+// Columns that are not mapped to a field and mapped fields
+// missing in the result set are reported as errors.
+func columnFields(columns []string, t reflect.Type) ([][]int, error) {
+	fields := structFields(t)
+	indexes := make([][]int, len(columns))
+	for n, column := range columns {
+		for _, field := range fields {
+			if field.column == column {
+				indexes[n] = field.index
+				break
+			}
+		}
+		if indexes[n] == nil {
+			return nil, fmt.Errorf("sqlbuilder: column %q is not mapped to a field of %s", column, t)
+		}
+	}
+	for _, field := range fields {
+		if !containsString(columns, field.column) {
+			return nil, fmt.Errorf("sqlbuilder: column %q of %s is missing in the result set", field.column, t)
+		}
+	}
+	return indexes, nil
+}
+
+// scanStruct scans the current row into fields of a struct value.
+func scanStruct(rows *sql.Rows, v reflect.Value, columns []string, indexes [][]int) error {
+	ptrs := make([]interface{}, len(indexes))
+	for n, index := range indexes {
+		ptr, ok := fieldAddr(v, index)
+		if !ok {
+			return fmt.Errorf("sqlbuilder: column %q can not be scanned into an unexported nil struct pointer", columns[n])
+		}
+		ptrs[n] = ptr
+	}
+	return rows.Scan(ptrs...)
+}
@@ -0,0 +1,113 @@
+package sqlbuilder_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sqlbuilder"
+)
+
+type Audit struct {
+	CreatedBy string `db:"created_by"`
+}
+
+type account struct {
+	ID       int64  `db:"id"`
+	Email    string `db:"email"`
+	Password string `db:"-"`
+	note     string `db:"note"`
+	*Audit
+}
+
+type org struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+func TestSelectStruct(t *testing.T) {
+	var a account
+	q := sqlbuilder.From("accounts").
+		SelectStruct(&a).
+		Where("id = ?", 42)
+	defer q.Close()
+	assert.Equal(t, "SELECT id, email, created_by FROM accounts WHERE id = ?", q.String())
+	assert.Equal(t, []interface{}{&a.ID, &a.Email, &a.Audit.CreatedBy}, q.Dest())
+
+	var o org
+	q2 := sqlbuilder.From("accounts a").
+		SelectStructAs("a", &a).
+		Join("orgs o", "o.id = a.org_id").
+		SelectStructAs("o", &o)
+	defer q2.Close()
+	assert.Equal(t, "SELECT a.id, a.email, a.created_by, o.id, o.name FROM accounts a JOIN orgs o ON (o.id = a.org_id)", q2.String())
+	assert.Equal(t, []interface{}{&a.ID, &a.Email, &a.Audit.CreatedBy, &o.ID, &o.Name}, q2.Dest())
+	assert.Equal(t, []interface{}{&o.ID, &o.Name}, sqlbuilder.Fields(&o))
+}
+
+func TestQueryAll(t *testing.T) {
+	fake := &fakeDB{
+		columns: []string{"name", "id"},
+		rows:    [][]driver.Value{{"acme", int64(1)}, {"initech", int64(2)}},
+	}
+	db := fake.open()
+	defer db.Close()
+
+	var orgs []org
+	err := sqlbuilder.From("orgs").
+		Select("name, id").
+		QueryAllAndClose(context.Background(), db, &orgs)
+	assert.NoError(t, err)
+	assert.Equal(t, []org{{ID: 1, Name: "acme"}, {ID: 2, Name: "initech"}}, orgs)
+
+	var ptrs []*org
+	err = sqlbuilder.From("orgs").
+		SelectStruct(&org{}).
+		QueryAllAndClose(context.Background(), db, &ptrs)
+	assert.NoError(t, err)
+	assert.Equal(t, []*org{{ID: 1, Name: "acme"}, {ID: 2, Name: "initech"}}, ptrs)
+
+	fake.columns = []string{"id", "name", "extra"}
+	err = sqlbuilder.From("orgs").Select("*").QueryAllAndClose(context.Background(), db, &orgs)
+	assert.EqualError(t, err, `sqlbuilder: column "extra" is not mapped to a field of sqlbuilder_test.org`)
+
+	fake.columns = []string{"id"}
+	err = sqlbuilder.From("orgs").Select("id").QueryAllAndClose(context.Background(), db, &orgs)
+	assert.EqualError(t, err, `sqlbuilder: column "name" of sqlbuilder_test.org is missing in the result set`)
+}
+
+type profile struct {
+	ID        int64  `db:"id,pk"`
+	Name      string `db:"name"`
+	Email     string `db:"email,omitempty"`
+	Age       int    `db:"age"`
+	CreatedAt string `db:"created_at,readonly"`
+}
+
+func TestSetStruct(t *testing.T) {
+	p := profile{Name: "John", Age: 0, CreatedAt: "now"}
+	q := sqlbuilder.InsertInto("profiles").SetStruct(p)
+	defer q.Close()
+	assert.Equal(t, "INSERT INTO profiles (name, age) VALUES (?, ?)", q.String())
+	assert.Equal(t, []interface{}{"John", 0}, q.Args())
+
+	p.ID = 7
+	q2 := sqlbuilder.UsingPostgresql().
+		Update("profiles").
+		SetStruct(&p, sqlbuilder.Only("name", "email", "id")).
+		Where("id = ?", p.ID)
+	defer q2.Close()
+	assert.Equal(t, "UPDATE profiles SET name=$1, email=$2 WHERE id = $3", q2.String())
+	assert.Equal(t, []interface{}{"John", "", int64(7)}, q2.Args())
+
+	q3 := sqlbuilder.Update("profiles").SetStruct(p, sqlbuilder.SkipZero())
+	defer q3.Close()
+	assert.Equal(t, "UPDATE profiles SET name=?", q3.String())
+
+	q4 := sqlbuilder.Update("profiles").SetStruct(p, sqlbuilder.Only()).Where("id = ?", p.ID)
+	defer q4.Close()
+	assert.Equal(t, "UPDATE profiles WHERE id = ?", q4.String())
+	assert.Equal(t, []interface{}{int64(7)}, q4.Args())
+}
@@ -1,6 +1,10 @@
 package sqlbuilder
 
 import (
+	"bytes"
+	"database/sql/driver"
+	"reflect"
+	"time"
 	"unsafe"
 )
 
@@ -26,3 +30,216 @@ func insertAt(dest, src []interface{}, index int) []interface{} {
 func bufferToString(buffer *[]byte) string {
 	return *(*string)(unsafe.Pointer(buffer))
 }
+
+// isParenthesized reports if a part s is to be glued to the sql built so far
+// without a separating space, like the column list of an INSERT statement.
+func isParenthesized(sql, s []byte) bool {
+	return (len(sql) > 0 && sql[len(sql)-1] == '(') || (len(s) > 0 && s[0] == ')')
+}
+
+// verbLen returns the length of a statement verb s starts with,
+// including the DISTINCT keyword if any.
+func verbLen(s []byte) int {
+	k := bytes.IndexByte(s, ' ')
+	if k < 0 {
+		return len(s)
+	}
+	if rest := s[k+1:]; len(rest) >= 8 && bytes.EqualFold(rest[:8], []byte("DISTINCT")) && (len(rest) == 8 || rest[8] == ' ') {
+		k += 9
+	}
+	return k
+}
+
+// skipLiteral returns a position of the last character of a quoted string,
+// a quoted identifier or a comment starting at pos,
+// or pos itself if there is none.
+// A doubled quote character is skipped as two quoted fragments.
+func skipLiteral(s []byte, pos int) int {
+	switch c := s[pos]; c {
+	case '\'', '"', '`':
+		return skipTo(s, pos+1, string(c))
+	case '-':
+		if pos < len(s)-1 && s[pos+1] == '-' {
+			return skipTo(s, pos+2, "\n")
+		}
+	case '/':
+		if pos < len(s)-1 && s[pos+1] == '*' {
+			return skipTo(s, pos+2, "*/")
+		}
+	}
+	return pos
+}
+
+// skipTo returns a position of the last character of the first
+// occurrence of a terminator in s starting from pos,
+// or the position of the last character of s if there is none.
+func skipTo(s []byte, pos int, terminator string) int {
+	for ; pos <= len(s)-len(terminator); pos++ {
+		if string(s[pos:pos+len(terminator)]) == terminator {
+			return pos + len(terminator) - 1
+		}
+	}
+	return len(s) - 1
+}
+
+// isScalar reports if a value is a string, a number, a boolean or a time.
+// Scalar values are safe to be used as map keys, unlike values of other
+// comparable types, which can hold slices or maps in interface fields.
+func isScalar(v interface{}) bool {
+	if _, ok := v.(time.Time); ok {
+		return true
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// paramNameEnd returns the position right after a named parameter
+// name starting at pos.
+func paramNameEnd(s []byte, pos int) int {
+	for i := pos; i < len(s); i++ {
+		c := s[i]
+		isLetter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !isLetter && (i == pos || c < '0' || c > '9') {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// listValue returns a slice or an array value to be expanded into a list of
+// placeholders. Byte slices and driver.Valuer values are not lists.
+// Values wrapped with Any are unwrapped.
+func listValue(arg interface{}) (v reflect.Value, ok bool) {
+	switch a := arg.(type) {
+	case nil, []byte, driver.Valuer:
+		return v, false
+	case arrayList:
+		arg = a.values
+	}
+	v = reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return v, v.Type().Elem().Kind() != reflect.Uint8
+	}
+	return v, false
+}
+
+// hasList reports if any of args is to be expanded into a list of placeholders.
+func hasList(args []interface{}) bool {
+	for _, arg := range args {
+		if _, ok := listValue(arg); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// skipSpaces returns a position of the first non-space character in s
+// starting from pos.
+func skipSpaces(s []byte, pos int) int {
+	for pos < len(s) && isSpace(s[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// trimSpaces returns a length of s without trailing spaces.
+func trimSpaces(s []byte, end int) int {
+	for end > 0 && isSpace(s[end-1]) {
+		end--
+	}
+	return end
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '.' || c == '"' || c == '`' || c == '[' || c == ']' || c == '$' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// hasKeyword reports if s[:end] ends with a given keyword preceded
+// by a space or a parenthesis.
+func hasKeyword(s []byte, end int, keyword string) bool {
+	start := end - len(keyword)
+	if start < 0 || !bytes.EqualFold(s[start:end], []byte(keyword)) {
+		return false
+	}
+	return start == 0 || isSpace(s[start-1]) || s[start-1] == ')'
+}
+
+// inPredicate looks for "operand IN (" or "operand NOT IN (" at the end
+// of s and returns positions the IN (or NOT IN) keyword and the operand start at.
+// keyword is -1 if s does not end with an IN predicate.
+// operand is -1 if the operand is neither an identifier, nor a function call,
+// nor a parenthesized expression.
+func inPredicate(s []byte) (keyword, operand int, not bool) {
+	end := trimSpaces(s, len(s))
+	if end == 0 || s[end-1] != '(' {
+		return -1, -1, false
+	}
+	end = trimSpaces(s, end-1)
+	if !hasKeyword(s, end, "IN") {
+		return -1, -1, false
+	}
+	keyword = end - 2
+	end = trimSpaces(s, keyword)
+	if hasKeyword(s, end, "NOT") {
+		not = true
+		keyword = end - 3
+		end = trimSpaces(s, keyword)
+	}
+	operand = end
+	if operand > 0 && s[operand-1] == ')' {
+		// A parenthesized expression or a function call
+		depth := 0
+		for operand > 0 {
+			operand--
+			if s[operand] == ')' {
+				depth++
+			} else if s[operand] == '(' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		if depth != 0 {
+			return keyword, -1, not
+		}
+	}
+	for operand > 0 && isIdentChar(s[operand-1]) {
+		operand--
+	}
+	if operand == end || !isOperandStart(s, operand) {
+		return keyword, -1, not
+	}
+	return keyword, operand, not
+}
+
+// isOperandStart reports if s[:pos] ends where an operand of
+// a predicate may start: at a parenthesis, a comma or a keyword
+// like AND or WHERE. It is not the case for "a + b" or "a::text".
+func isOperandStart(s []byte, pos int) bool {
+	end := trimSpaces(s, pos)
+	if end == 0 || s[end-1] == '(' || s[end-1] == ',' {
+		return true
+	}
+	if end == pos {
+		return false
+	}
+	for _, keyword := range []string{"AND", "OR", "NOT", "WHERE", "ON", "HAVING", "WHEN", "THEN", "ELSE"} {
+		if hasKeyword(s, end, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// debugMode is set to 1 if statements are to be validated before execution.
+var debugMode int32
+
+/*
+SetDebug makes Query, QueryRow and Exec methods call Validate
+before a statement is passed to an Executor:
+	if os.Getenv("SQL_DEBUG") != "" {
+		sqlbuilder.SetDebug(true)
+	}
+*/
+func SetDebug(debug bool) {
+	var v int32
+	if debug {
+		v = 1
+	}
+	atomic.StoreInt32(&debugMode, v)
+}
+
+// PlaceholderError is returned by Validate if the number of ? placeholders
+// of a statement clause does not match the number of its arguments.
+type PlaceholderError struct {
+	// Clause is a name of the clause, like WHERE or SET
+	Clause string
+	// Expr is an SQL fragment having the mismatch
+	Expr         string
+	Placeholders int
+	Args         int
+}
+
+func (e *PlaceholderError) Error() string {
+	return fmt.Sprintf("sqlbuilder: %s clause %q has %d placeholders, but %d arguments are given",
+		e.Clause, e.Expr, e.Placeholders, e.Args)
+}
+
+// clauseNames holds clause names to report validation errors with.
+var clauseNames = map[int]string{
+	posStart:        "statement",
+	posWith:         "WITH",
+	posInsert:       "INSERT",
+	posInsertFields: "INSERT",
+	posValues:       "VALUES",
+	posUpsert:       "upsert",
+	posMerge:        "MERGE",
+	posMergeUsing:   "USING",
+	posMergeWhen:    "WHEN",
+	posDelete:       "DELETE",
+	posUpdate:       "UPDATE",
+	posSet:          "SET",
+	posSelect:       "SELECT",
+	posInto:         "INTO",
+	posFrom:         "FROM",
+	posWhere:        "WHERE",
+	posGroupBy:      "GROUP BY",
+	posHaving:       "HAVING",
+	posUnion:        "UNION",
+	posOrderBy:      "ORDER BY",
+	posLimit:        "LIMIT",
+	posOffset:       "OFFSET",
+	posReturning:    "RETURNING",
+	posEnd:          "statement",
+}
+
+/*
+Validate checks if the number of ? placeholders of every statement clause
+matches the number of arguments passed along with it:
+	err := sqlbuilder.From("users").
+		Select("id").
+		Where("a = ? AND b = ?", x).
+		Validate()
+	// sqlbuilder: WHERE clause "WHERE a = ? AND b = ?" has 2 placeholders, but 1 arguments are given
+Escaped \? placeholders and question marks inside quoted strings,
+quoted identifiers and comments are not counted.
+All the mismatches found are reported as *PlaceholderError values.
+Query, QueryRow and Exec methods call Validate if debug mode
+is turned on by SetDebug.
+*/
+func (stmt *statement) Validate() error {
+	var errs errorList
+	for _, part := range stmt.parts {
+		expr := stmt.buffer.B[part.bufLow:part.bufHigh]
+		if n := countPlaceholders(expr); n != part.argLen {
+			errs = append(errs, &PlaceholderError{
+				Clause:       clauseNames[part.position-part.position%100],
+				Expr:         string(expr),
+				Placeholders: n,
+				Args:         part.argLen,
+			})
+		}
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return errs
+}
+
+// check returns an error to be reported instead of the statement execution.
+func (stmt *statement) check() error {
+	if err := stmt.Err(); err != nil {
+		return err
+	}
+	if atomic.LoadInt32(&debugMode) == 1 {
+		return stmt.Validate()
+	}
+	return nil
+}
+
+// countPlaceholders returns the number of ? placeholders in s
+// skipping \? escapes, quoted strings and identifiers and comments
+// the same way writeExpr does.
+func countPlaceholders(s []byte) (n int) {
+	for pos := 0; pos < len(s); pos++ {
+		switch s[pos] {
+		case '\\':
+			if pos < len(s)-1 && s[pos+1] == '?' {
+				pos++
+			}
+		case '?':
+			n++
+		default:
+			pos = skipLiteral(s, pos)
+		}
+	}
+	return n
+}
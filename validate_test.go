@@ -0,0 +1,55 @@
+package sqlbuilder_test
+
+import (
+	"context"
+	"errors"
+	"sqlbuilder"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	stmt := sqlbuilder.From("users").
+		Select("id").
+		Where(`name <> '?' AND "a?" = ? AND tags \? 'x' /* ? */ AND id = ?`, 1, 2).
+		Limit(10)
+	defer stmt.Close()
+	assert.NoError(t, stmt.Validate())
+
+	stmt = sqlbuilder.WithDialect(sqlbuilder.PostgreSQL).
+		From("users").
+		Select("id").
+		Where("x = '?' AND y = ? -- ?\n", 1)
+	defer stmt.Close()
+	assert.NoError(t, stmt.Validate())
+	assert.NoError(t, stmt.Err())
+	assert.Equal(t, "SELECT id FROM users WHERE x = '?' AND y = $1 -- ?\n", stmt.String())
+	assert.Equal(t, []interface{}{1}, stmt.Args())
+
+	stmt = sqlbuilder.Update("users").
+		Set("name", "Jane").
+		Where("a = ? AND b = ?", 1)
+	defer stmt.Close()
+	err := stmt.Validate()
+	var perr *sqlbuilder.PlaceholderError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, "WHERE", perr.Clause)
+		assert.Equal(t, "WHERE a = ? AND b = ?", perr.Expr)
+		assert.Equal(t, 2, perr.Placeholders)
+		assert.Equal(t, 1, perr.Args)
+	}
+	assert.EqualError(t, err, `sqlbuilder: WHERE clause "WHERE a = ? AND b = ?" has 2 placeholders, but 1 arguments are given`)
+}
+
+func TestValidateInDebugMode(t *testing.T) {
+	fake := &fakeDB{}
+	db := fake.open()
+	defer db.Close()
+
+	sqlbuilder.SetDebug(true)
+	defer sqlbuilder.SetDebug(false)
+	_, err := sqlbuilder.From("users").Select("id").Where("a = ?", 1, 2).ExecAndClose(context.Background(), db)
+	assert.EqualError(t, err, `sqlbuilder: WHERE clause "WHERE a = ?" has 1 placeholders, but 2 arguments are given`)
+	assert.Empty(t, fake.queries)
+}